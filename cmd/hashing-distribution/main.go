@@ -1,21 +1,17 @@
 package main
 
 import (
+	"app/pkg/hashring"
+	"app/pkg/interfaces"
 	"bufio"
-	"crypto/md5"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
-	"encoding/binary"
 	"fmt"
 	"log"
 	"math"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
-
-	"github.com/spaolacci/murmur3"
 )
 
 // HashFunction representa uma função de hash
@@ -24,6 +20,16 @@ type HashFunction struct {
 	Func func(string) uint64
 }
 
+// Strategy representa uma estratégia de distribuição de chaves entre shards.
+// Build recebe a função de hash e retorna a função de lookup da chave para o shard.
+type Strategy struct {
+	Name  string
+	Build func(hashFunc func(string) uint64, numReplicas int) func(key string) string
+}
+
+// shards utilizados em todas as análises
+var shards = []string{"shard01", "shard02", "shard03"}
+
 // Node representa um nó no hash ring
 type Node struct {
 	ID   string
@@ -43,47 +49,8 @@ type DistributionResult struct {
 	Duration     time.Duration
 }
 
-// Implementações das funções de hash
-func hashKeyMD5(s string) uint64 {
-	s = strings.ToLower(s)
-	hasher := md5.New()
-	hasher.Write([]byte(s))
-	hashBytes := hasher.Sum(nil)
-	return binary.BigEndian.Uint64(hashBytes[:8])
-}
-
-func hashKeySHA1(s string) uint64 {
-	s = strings.ToLower(s)
-	hasher := sha1.New()
-	hasher.Write([]byte(s))
-	hashBytes := hasher.Sum(nil)
-	return binary.BigEndian.Uint64(hashBytes[:8])
-}
-
-func hashKeySHA256(s string) uint64 {
-	s = strings.ToLower(s)
-	hasher := sha256.New()
-	hasher.Write([]byte(s))
-	hashBytes := hasher.Sum(nil)
-	return binary.BigEndian.Uint64(hashBytes[:8])
-}
-
-func hashKeySHA512(s string) uint64 {
-	s = strings.ToLower(s)
-	hasher := sha512.New()
-	hasher.Write([]byte(s))
-	hashBytes := hasher.Sum(nil)
-	return binary.BigEndian.Uint64(hashBytes[:8])
-}
-
-func hashKeyMurmur(s string) uint64 {
-	s = strings.ToLower(s)
-	return murmur3.Sum64([]byte(s))
-}
-
 // createHashRing cria um hash ring com 3 shards usando a função de hash especificada
 func createHashRing(hashFunc func(string) uint64, numReplicas int) []Node {
-	shards := []string{"shard01", "shard02", "shard03"}
 	var nodes []Node
 
 	for _, shard := range shards {
@@ -120,17 +87,48 @@ func getShardForKey(nodes []Node, key string, hashFunc func(string) uint64) stri
 	return nodes[idx].ID
 }
 
+// consistentStrategy constrói o hash ring com réplicas virtuais e busca binária
+func consistentStrategy(hashFunc func(string) uint64, numReplicas int) func(key string) string {
+	nodes := createHashRing(hashFunc, numReplicas)
+	return func(key string) string {
+		return getShardForKey(nodes, key, hashFunc)
+	}
+}
+
+// jumpStrategy utiliza Jump Consistent Hash, onde cada shard é um bucket numerado
+func jumpStrategy(hashFunc func(string) uint64, numReplicas int) func(key string) string {
+	return func(key string) string {
+		return shards[hashring.JumpHash(hashFunc(key), len(shards))]
+	}
+}
+
+// rendezvousStrategy utiliza Rendezvous Hashing, escolhendo o shard com maior score para a chave
+func rendezvousStrategy(hashFunc func(string) uint64, numReplicas int) func(key string) string {
+	return func(key string) string {
+		var winner string
+		var best uint64
+		for _, shard := range shards {
+			score := hashFunc(shard + "-" + key)
+			if winner == "" || score > best {
+				winner = shard
+				best = score
+			}
+		}
+		return winner
+	}
+}
+
 // analyzeDistribution analisa a distribuição das chaves usando um algoritmo específico
-func analyzeDistribution(keys []string, hashFunc HashFunction, numReplicas int) DistributionResult {
+func analyzeDistribution(keys []string, hashFunc HashFunction, strategy Strategy, numReplicas int) DistributionResult {
 	start := time.Now()
 
-	// Criar hash ring
-	nodes := createHashRing(hashFunc.Func, numReplicas)
+	// Criar estrutura de lookup da estratégia
+	lookup := strategy.Build(hashFunc.Func, numReplicas)
 
 	// Distribuir chaves
 	distribution := make(map[string]int)
 	for _, key := range keys {
-		shard := getShardForKey(nodes, key, hashFunc.Func)
+		shard := lookup(key)
 		distribution[shard]++
 	}
 
@@ -179,7 +177,7 @@ func analyzeDistribution(keys []string, hashFunc HashFunction, numReplicas int)
 	}
 
 	return DistributionResult{
-		Algorithm:    hashFunc.Name,
+		Algorithm:    fmt.Sprintf("%s [%s]", hashFunc.Name, strategy.Name),
 		Distribution: distribution,
 		TotalKeys:    totalKeys,
 		StdDev:       stdDev,
@@ -229,7 +227,6 @@ func printResults(results []DistributionResult) {
 		fmt.Printf("\n%s\n", result.Algorithm)
 
 		// Distribuição por shard
-		shards := []string{"shard01", "shard02", "shard03"}
 		for _, shard := range shards {
 			count := result.Distribution[shard]
 			percentage := float64(count) / float64(totalKeys) * 100.0
@@ -247,9 +244,84 @@ func printResults(results []DistributionResult) {
 	}
 }
 
+// hashSeed lê a seed opcional da variável HASHING_SEED
+func hashSeed() (uint64, error) {
+	value := os.Getenv("HASHING_SEED")
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 0, 64)
+}
+
+// loadRing cria um hash ring a partir de um arquivo de snapshot (JSON ou binário)
+func loadRing(filename string) (interfaces.HashRing, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler snapshot: %v", err)
+	}
+	snapshot, err := hashring.UnmarshalSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	return hashring.NewHashRingFromSnapshot(snapshot)
+}
+
+// runDiff compara dois snapshots do hash ring e exibe a fração do keyspace movida
+// entre cada par de shards e, se informado um arquivo de chaves, as chaves movidas
+func runDiff(args []string) {
+	if len(args) < 2 || len(args) > 3 {
+		fmt.Printf("Uso: %s diff <snapshot-antes> <snapshot-depois> [arquivo-de-chaves]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	before, err := loadRing(args[0])
+	if err != nil {
+		log.Fatalf("Erro ao carregar %s: %v", args[0], err)
+	}
+	after, err := loadRing(args[1])
+	if err != nil {
+		log.Fatalf("Erro ao carregar %s: %v", args[1], err)
+	}
+
+	fmt.Printf("\nMovimentação de chaves: %s -> %s\n", args[0], args[1])
+	printDiff("Keyspace (amostra sintética)", hashring.DiffRings(before, after, hashring.SampleKeys(hashring.DefaultDiffSampleSize), false))
+
+	if len(args) == 3 {
+		keys, err := readKeysFromFile(args[2])
+		if err != nil {
+			log.Fatalf("Erro ao ler arquivo: %v", err)
+		}
+		diff := hashring.DiffRings(before, after, keys, true)
+		printDiff("Chaves de "+args[2], diff)
+
+		if len(diff.Moves) > 0 {
+			fmt.Printf("  Lista de chaves movidas:\n")
+		}
+		for _, move := range diff.Moves {
+			fmt.Printf("    %s: %s -> %s\n", move.Key, move.From, move.To)
+		}
+	}
+}
+
+// printDiff exibe o resumo da movimentação de chaves entre shards
+func printDiff(title string, diff hashring.RingDiff) {
+	fmt.Printf("\n%s\n", title)
+	fmt.Printf("  Chaves analisadas: %d\n", diff.TotalKeys)
+	fmt.Printf("  Chaves movidas: %d (%5.1f%%)\n", diff.MovedKeys, diff.MovedFraction*100.0)
+	for _, movement := range diff.Movements {
+		fmt.Printf("    %s -> %s: %d chaves (%5.1f%%)\n", movement.From, movement.To, movement.Keys, movement.Fraction*100.0)
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+
 	if len(os.Args) != 2 {
 		fmt.Printf("Uso: %s <caminho-para-arquivo-de-chaves>\n", os.Args[0])
+		fmt.Printf("     %s diff <snapshot-antes> <snapshot-depois> [arquivo-de-chaves]\n", os.Args[0])
 		os.Exit(1)
 	}
 
@@ -270,22 +342,39 @@ func main() {
 		log.Fatalf("Nenhuma chave encontrada no arquivo")
 	}
 
-	// Definir funções de hash disponíveis
-	hashFunctions := []HashFunction{
-		{"SHA-512", hashKeySHA512},
-		{"SHA-256", hashKeySHA256},
-		{"SHA-1", hashKeySHA1},
-		{"MD5", hashKeyMD5},
-		{"MURMUR", hashKeyMurmur},
+	// Definir funções de hash disponíveis, com a mesma seed usada pelo router
+	seed, err := hashSeed()
+	if err != nil {
+		log.Fatalf("HASHING_SEED inválida: %v", err)
+	}
+
+	var hashFunctions []HashFunction
+	for _, algorithm := range hashring.SupportedHashAlgorithms {
+		hashFunc, err := hashring.NewHashFunc(algorithm, seed)
+		if err != nil {
+			// MURMUR3 e CRC32 não aceitam seeds acima de 32 bits
+			log.Printf("Ignorando %s: %v", algorithm, err)
+			continue
+		}
+		hashFunctions = append(hashFunctions, HashFunction{string(algorithm), hashFunc})
 	}
 
-	// Analisar cada algoritmo
+	// Definir estratégias de distribuição comparadas
+	strategies := []Strategy{
+		{"CONSISTENT", consistentStrategy},
+		{"JUMP", jumpStrategy},
+		{"RENDEZVOUS", rendezvousStrategy},
+	}
+
+	// Analisar cada algoritmo em cada estratégia
 	var results []DistributionResult
 	numReplicas := 10
 
-	for _, hashFunc := range hashFunctions {
-		result := analyzeDistribution(keys, hashFunc, numReplicas)
-		results = append(results, result)
+	for _, strategy := range strategies {
+		for _, hashFunc := range hashFunctions {
+			result := analyzeDistribution(keys, hashFunc, strategy, numReplicas)
+			results = append(results, result)
+		}
 	}
 
 	// Exibir resultados
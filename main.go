@@ -1,15 +1,25 @@
 package main
 
 import (
+	"app/pkg/hashring"
 	"app/pkg/interfaces"
+	"app/pkg/proxy"
 	"app/pkg/setup"
 	"app/pkg/sharding"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -18,15 +28,19 @@ import (
 
 // ProxyServer encapsula as dependências e configurações do servidor
 type ProxyServer struct {
+	configManager   interfaces.ConfigManager
 	router          interfaces.ShardRouter
 	metricsRecorder interfaces.MetricsRecorder
+	proxyConfig     interfaces.ProxyConfig
+	serverConfig    interfaces.ServerConfig
 	port            string
 }
 
 // PrometheusMetricsRecorder implementa a interface MetricsRecorder
 type PrometheusMetricsRecorder struct {
-	requestsCounter prometheus.CounterVec
-	responseCounter prometheus.CounterVec
+	requestsCounter   prometheus.CounterVec
+	responseCounter   prometheus.CounterVec
+	missingKeyCounter prometheus.CounterVec
 }
 
 // Garantir que PrometheusMetricsRecorder implementa a interface
@@ -40,6 +54,10 @@ func (pm *PrometheusMetricsRecorder) RecordResponse(shard string, statusCode int
 	pm.responseCounter.WithLabelValues(shard, strconv.Itoa(statusCode)).Inc()
 }
 
+func (pm *PrometheusMetricsRecorder) RecordMissingKey(policy string) {
+	pm.missingKeyCounter.WithLabelValues(policy).Inc()
+}
+
 // NewPrometheusMetricsRecorder cria uma nova instância do recorder de métricas
 func NewPrometheusMetricsRecorder() *PrometheusMetricsRecorder {
 	requestsCounter := prometheus.NewCounterVec(
@@ -56,26 +74,41 @@ func NewPrometheusMetricsRecorder() *PrometheusMetricsRecorder {
 		},
 		[]string{"shard", "status"},
 	)
+	missingKeyCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "shard_router_missing_key_total",
+			Help: "Total number of HTTP requests without sharding key, by missing key policy",
+		},
+		[]string{"policy"},
+	)
 
 	return &PrometheusMetricsRecorder{
-		requestsCounter: *requestsCounter,
-		responseCounter: *responseCounter,
+		requestsCounter:   *requestsCounter,
+		responseCounter:   *responseCounter,
+		missingKeyCounter: *missingKeyCounter,
 	}
 }
 
 // NewProxyServer cria uma nova instância do servidor proxy
 func NewProxyServer(port string) *ProxyServer {
-	shardingKey := os.Getenv("SHARDING_KEY")
-	if shardingKey == "" {
-		log.Fatal("SHARDING_KEY environment variable is required")
+	configManager := setup.NewConfigManager()
+	keyExtractor, err := configManager.GetKeyExtractor()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	router := sharding.NewShardRouter(shardingKey)
+	router, err := sharding.NewShardRouterWithExtractor(keyExtractor)
+	if err != nil {
+		log.Fatal(err)
+	}
 	metricsRecorder := NewPrometheusMetricsRecorder()
 
 	return &ProxyServer{
+		configManager:   configManager,
 		router:          router,
 		metricsRecorder: metricsRecorder,
+		proxyConfig:     configManager.GetProxyConfig(),
+		serverConfig:    configManager.GetServerConfig(),
 		port:            port,
 	}
 }
@@ -84,54 +117,185 @@ func NewProxyServer(port string) *ProxyServer {
 type ProxyHandler struct {
 	router          interfaces.ShardRouter
 	metricsRecorder interfaces.MetricsRecorder
+	proxy           *proxy.Proxy
 }
 
 // Garantir que ProxyHandler implementa a interface
 var _ interfaces.ProxyHandler = (*ProxyHandler)(nil)
 
-// ServeHTTP implementa o handler HTTP para o proxy
+// ServeHTTP implementa o handler HTTP para o proxy. Requisições sem a chave de sharding
+// seguem a política de SHARDING_MISSING_KEY_POLICY e são contabilizadas por política.
+// Upgrades e chamadas gRPC não são replicadas pela política broadcast, seguindo para um único shard.
 func (ph *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	shardKey := ph.router.GetShardingKey(r)
-	shardURL := ph.router.GetShardHost(shardKey)
+	shardKey, ok := ph.router.LookupShardingKey(r)
+	if ok {
+		shardURL := ph.router.GetShardHost(shardKey)
+		if shardURL == "" {
+			writeJSONError(w, r, http.StatusServiceUnavailable, "no shard available")
+			return
+		}
+		ph.forward(w, r, shardURL)
+		return
+	}
+
+	policy := ph.router.MissingKeyPolicy()
+	ph.metricsRecorder.RecordMissingKey(policy)
+
+	shards := ph.router.GetMissingKeyShards()
+	switch {
+	case policy == sharding.MissingKeyReject:
+		writeJSONError(w, r, http.StatusBadRequest, "missing sharding key")
+	case len(shards) == 0:
+		writeJSONError(w, r, http.StatusServiceUnavailable, "no shard available")
+	case policy == sharding.MissingKeyBroadcast && !proxy.IsUpgrade(r) && !proxy.IsGRPC(r):
+		ph.broadcast(w, r, shards)
+	default:
+		ph.forward(w, r, shards[0])
+	}
+}
 
-	targetURL, err := url.Parse(shardURL + r.URL.Path)
+// forward encaminha a requisição para o shard e copia a resposta. Requisições de upgrade
+// aceitas pelo shard, como WebSocket, mantêm a conexão até o fim da sessão.
+func (ph *ProxyHandler) forward(w http.ResponseWriter, r *http.Request, shardURL string) {
+	ph.router.StartRequest(shardURL)
+	defer ph.router.FinishRequest(shardURL)
+
+	proxyReq, err := ph.proxy.NewRequest(r, shardURL)
 	if err != nil {
-		http.Error(w, "Invalid target URL", http.StatusBadRequest)
+		log.Printf("Error proxying %s %s: %v", r.Method, r.URL.Path, err)
+		writeError(w, r, http.StatusBadGateway, "Invalid shard URL")
 		return
 	}
 
 	ph.metricsRecorder.RecordRequest(shardURL)
 
-	proxyReq, err := http.NewRequest(r.Method, targetURL.String(), r.Body)
+	resp, err := ph.proxy.RoundTrip(proxyReq)
 	if err != nil {
-		http.Error(w, "Failed to create request", http.StatusInternalServerError)
+		log.Printf("Error proxying %s %s to %s: %v", r.Method, r.URL.Path, shardURL, err)
+		writeError(w, r, http.StatusBadGateway, "Bad Gateway")
 		return
 	}
-	proxyReq.Header = r.Header
+	defer resp.Body.Close()
 
-	client := &http.Client{}
-	resp, err := client.Do(proxyReq)
+	ph.metricsRecorder.RecordResponse(shardURL, resp.StatusCode)
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		if err := ph.proxy.HandleUpgrade(w, r, resp); err != nil {
+			log.Printf("Error proxying %s upgrade to %s: %v", resp.Header.Get("Upgrade"), shardURL, err)
+			if errors.Is(err, proxy.ErrUpgrade) {
+				http.Error(w, "Bad Gateway", http.StatusBadGateway)
+			}
+		}
+		return
+	}
+	if err := ph.proxy.WriteResponse(w, resp); err != nil {
+		log.Printf("Error copying response from %s: %v", shardURL, err)
+	}
+}
+
+// broadcast envia a requisição para todos os shards em paralelo e responde com a primeira
+// resposta 2xx, na ordem dos shards, ou com a primeira resposta recebida quando nenhum
+// shard teve sucesso. O corpo é lido uma vez, até o limite de BroadcastBodyLimit,
+// e reenviado a cada shard; corpos maiores são recusados com 413.
+func (ph *ProxyHandler) broadcast(w http.ResponseWriter, r *http.Request, shards []string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, ph.proxy.Config().BroadcastBodyLimit))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadGateway)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
-	defer resp.Body.Close()
 
-	for k, v := range resp.Header {
-		w.Header()[k] = v
+	for _, shardURL := range shards {
+		ph.router.StartRequest(shardURL)
+		ph.metricsRecorder.RecordRequest(shardURL)
 	}
 
-	ph.metricsRecorder.RecordResponse(shardURL, resp.StatusCode)
+	responses := make([]*http.Response, len(shards))
+	var wg sync.WaitGroup
+	for i, shardURL := range shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			proxyReq, err := ph.proxy.NewRequest(r, shardURL)
+			if err != nil {
+				return
+			}
+			proxyReq.Body = io.NopCloser(bytes.NewReader(body))
+			proxyReq.ContentLength = int64(len(body))
+			resp, err := ph.proxy.RoundTrip(proxyReq)
+			if err != nil {
+				log.Printf("Error broadcasting %s %s to %s: %v", r.Method, r.URL.Path, shardURL, err)
+				return
+			}
+			responses[i] = resp
+		}()
+	}
+	wg.Wait()
+
+	var chosen *http.Response
+	for i, resp := range responses {
+		ph.router.FinishRequest(shards[i])
+		if resp == nil {
+			continue
+		}
+		ph.metricsRecorder.RecordResponse(shards[i], resp.StatusCode)
+		if chosen == nil || (chosen.StatusCode/100 != 2 && resp.StatusCode/100 == 2) {
+			chosen = resp
+		}
+	}
 
-	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	for _, resp := range responses {
+		if resp != nil && resp != chosen {
+			resp.Body.Close()
+		}
+	}
+	if chosen == nil {
+		http.Error(w, "All shards failed", http.StatusBadGateway)
+		return
+	}
+	defer chosen.Body.Close()
+	if err := ph.proxy.WriteResponse(w, chosen); err != nil {
+		log.Printf("Error copying broadcast response: %v", err)
+	}
+}
+
+// writeError responde com o status e uma mensagem de erro em texto. Chamadas gRPC
+// recebem o código gRPC equivalente ao status em uma resposta trailers-only.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if proxy.IsGRPC(r) {
+		proxy.WriteGRPCError(w, proxy.GRPCStatus(status), message)
+		return
+	}
+	http.Error(w, message, status)
 }
 
-// NewProxyHandler cria um novo handler de proxy
+// writeJSONError responde com o status e uma mensagem de erro em JSON, ou com o
+// código gRPC equivalente em chamadas gRPC
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if proxy.IsGRPC(r) {
+		proxy.WriteGRPCError(w, proxy.GRPCStatus(status), message)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// NewProxyHandler cria um novo handler de proxy com a configuração padrão de encaminhamento
 func NewProxyHandler(router interfaces.ShardRouter, metricsRecorder interfaces.MetricsRecorder) *ProxyHandler {
+	return NewProxyHandlerWithConfig(router, metricsRecorder, interfaces.ProxyConfig{})
+}
+
+// NewProxyHandlerWithConfig cria um novo handler de proxy com a configuração de
+// encaminhamento informada, normalmente obtida de ConfigManager.GetProxyConfig
+func NewProxyHandlerWithConfig(router interfaces.ShardRouter, metricsRecorder interfaces.MetricsRecorder, config interfaces.ProxyConfig) *ProxyHandler {
 	return &ProxyHandler{
 		router:          router,
 		metricsRecorder: metricsRecorder,
+		proxy:           proxy.New(config),
 	}
 }
 
@@ -140,9 +304,36 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// RingSnapshotHandler exporta o snapshot do hash ring em JSON ou, com ?format=binary,
+// no formato binário compacto. Permite comparar a topologia de réplicas do router.
+// A seed não é exportada, apenas a sua impressão digital.
+func RingSnapshotHandler(router interfaces.ShardRouter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := router.Snapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+
+		if r.URL.Query().Get("format") == "binary" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(hashring.MarshalSnapshotBinary(snapshot))
+			return
+		}
+
+		data, err := hashring.MarshalSnapshotJSON(snapshot)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
 // SetupRouter configura e inicializa o roteador de shards
 func (ps *ProxyServer) SetupRouter() error {
-	err := setup.InitWithRouter(ps.router)
+	err := setup.InitWithRouter(ps.configManager, ps.router)
 	if err != nil {
 		return err
 	}
@@ -168,18 +359,114 @@ func (ps *ProxyServer) Start() error {
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 		&prometheusRecorder.requestsCounter,
 		&prometheusRecorder.responseCounter,
+		&prometheusRecorder.missingKeyCounter,
 	)
 
 	// Setup dos handlers
-	proxyHandler := NewProxyHandler(ps.router, ps.metricsRecorder)
+	proxyHandler := NewProxyHandlerWithConfig(ps.router, ps.metricsRecorder, ps.proxyConfig)
 
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
 	mux.HandleFunc("/healthz", HealthCheckHandler)
 	mux.Handle("/", proxyHandler)
 
-	log.Printf("HTTP Proxy running on port %s", ps.port)
-	return http.ListenAndServe(":"+ps.port, mux)
+	server, err := ps.newServer(mux)
+	if err != nil {
+		return err
+	}
+	if ps.serverConfig.AdminPort != "" {
+		if err := ps.startAdmin(); err != nil {
+			return err
+		}
+	}
+	if ps.serverConfig.TLSCertFile != "" {
+		log.Printf("HTTPS Proxy running on port %s (HTTP/1.1 and HTTP/2)", ps.port)
+		return server.ListenAndServeTLS(ps.serverConfig.TLSCertFile, ps.serverConfig.TLSKeyFile)
+	}
+	if ps.serverConfig.GRPC {
+		log.Printf("HTTP Proxy running on port %s (HTTP/1.1 and h2c for gRPC)", ps.port)
+	} else {
+		log.Printf("HTTP Proxy running on port %s", ps.port)
+	}
+	return server.ListenAndServe()
+}
+
+// ShardRemoveHandler remove em tempo de execução o shard informado no parâmetro url.
+// A requisição precisa do cabeçalho "Authorization: Bearer <token>".
+func ShardRemoveHandler(router interfaces.ShardRouter, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		shard := r.URL.Query().Get("url")
+		shards := router.ListShards()
+		if shard == "" {
+			http.Error(w, "Missing url parameter", http.StatusBadRequest)
+			return
+		}
+		if !slices.Contains(shards, shard) {
+			http.Error(w, "Shard not found", http.StatusNotFound)
+			return
+		}
+		if len(shards) == 1 {
+			http.Error(w, "Cannot remove the last shard", http.StatusConflict)
+			return
+		}
+
+		router.RemoveShard(shard)
+		log.Printf("Shard %s removed through the admin port", shard)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AdminHandler cria o handler da porta administrativa, que exporta o snapshot do hash ring
+// em /ring. Fica fora da porta dos clientes, em que todos os paths são encaminhados aos shards.
+// Com um token, também aceita DELETE /shards para remover shards sem reiniciar o router.
+func AdminHandler(router interfaces.ShardRouter, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ring", RingSnapshotHandler(router))
+	if token != "" {
+		mux.HandleFunc("DELETE /shards", ShardRemoveHandler(router, token))
+	}
+	return mux
+}
+
+// startAdmin inicia o servidor da porta administrativa (ROUTER_ADMIN_PORT) em segundo plano
+func (ps *ProxyServer) startAdmin() error {
+	listener, err := net.Listen("tcp", ":"+ps.serverConfig.AdminPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen on ROUTER_ADMIN_PORT: %w", err)
+	}
+	log.Printf("Admin endpoints running on port %s", ps.serverConfig.AdminPort)
+	go func() {
+		if err := http.Serve(listener, AdminHandler(ps.router, ps.serverConfig.AdminToken)); err != nil {
+			log.Printf("Admin server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// newServer cria o servidor HTTP com os protocolos aceitos: HTTP/1.1 e HTTP/2 sobre TLS
+// e, no modo gRPC, HTTP/2 sem TLS (h2c), necessário para clientes gRPC em texto puro
+func (ps *ProxyServer) newServer(handler http.Handler) (*http.Server, error) {
+	if (ps.serverConfig.TLSCertFile == "") != (ps.serverConfig.TLSKeyFile == "") {
+		return nil, errors.New("ROUTER_TLS_CERT and ROUTER_TLS_KEY must be set together")
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(ps.serverConfig.GRPC)
+
+	return &http.Server{
+		Addr:      ":" + ps.port,
+		Handler:   handler,
+		Protocols: protocols,
+	}, nil
 }
 
 func main() {
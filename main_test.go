@@ -1,49 +1,125 @@
 package main
 
 import (
+	"app/pkg/hashring"
+	"app/pkg/interfaces"
+	"app/pkg/sharding"
+	"bufio"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // MockShardRouter para testes do main
 type MockShardRouter struct {
-	shardingKey   string
-	expectedShard string
-	initCalled    bool
-	shardsAdded   []string
+	shardingKey      string
+	expectedShard    string
+	initCalled       bool
+	shardsAdded      []string
+	startedRequests  map[string]int
+	finishedRequests map[string]int
+	missingKeyPolicy string
+	missingKeyShards []string
 }
 
-func (m *MockShardRouter) InitHashRing(size int) {
+func (m *MockShardRouter) InitHashRing(config interfaces.HashRingConfig) error {
 	m.initCalled = true
+	return nil
 }
 
-func (m *MockShardRouter) AddShard(shardHost string) {
+func (m *MockShardRouter) InitHashRingFromSnapshot(snapshot interfaces.RingSnapshot) error {
+	m.initCalled = true
+	return nil
+}
+
+func (m *MockShardRouter) Snapshot() (interfaces.RingSnapshot, error) {
+	nodes := make([]interfaces.SnapshotNode, len(m.shardsAdded))
+	for i, shard := range m.shardsAdded {
+		nodes[i] = interfaces.SnapshotNode{ID: shard, Weight: 1}
+	}
+	return interfaces.RingSnapshot{Type: "CONSISTENT", HashAlgorithm: "SHA512", Nodes: nodes}, nil
+}
+
+func (m *MockShardRouter) AddShard(shardHost string) error {
 	m.shardsAdded = append(m.shardsAdded, shardHost)
+	return nil
+}
+
+func (m *MockShardRouter) AddWeightedShard(shardHost string, weight int) error {
+	return m.AddShard(shardHost)
+}
+
+func (m *MockShardRouter) RemoveShard(shardHost string) {}
+
+func (m *MockShardRouter) ListShards() []string {
+	return m.shardsAdded
+}
+
+func (m *MockShardRouter) StartRequest(shardHost string) {
+	if m.startedRequests == nil {
+		m.startedRequests = make(map[string]int)
+	}
+	m.startedRequests[shardHost]++
+}
+
+func (m *MockShardRouter) FinishRequest(shardHost string) {
+	if m.finishedRequests == nil {
+		m.finishedRequests = make(map[string]int)
+	}
+	m.finishedRequests[shardHost]++
 }
 
 func (m *MockShardRouter) GetShardingKey(r *http.Request) string {
 	return r.Header.Get(m.shardingKey)
 }
 
+// LookupShardingKey considera a chave presente quando nenhuma política foi configurada no mock
+func (m *MockShardRouter) LookupShardingKey(r *http.Request) (string, bool) {
+	key := m.GetShardingKey(r)
+	return key, key != "" || m.missingKeyPolicy == ""
+}
+
+func (m *MockShardRouter) MissingKeyPolicy() string {
+	return m.missingKeyPolicy
+}
+
+func (m *MockShardRouter) GetMissingKeyShards() []string {
+	return m.missingKeyShards
+}
+
 func (m *MockShardRouter) GetShardHost(key string) string {
 	return m.expectedShard
 }
 
+func (m *MockShardRouter) GetShardHosts(key string, n int) []string {
+	return []string{m.expectedShard}
+}
+
 // MockMetricsRecorder para testes
 type MockMetricsRecorder struct {
-	requests  map[string]int
-	responses map[string]map[int]int
+	requests    map[string]int
+	responses   map[string]map[int]int
+	missingKeys map[string]int
 }
 
 func NewMockMetricsRecorder() *MockMetricsRecorder {
 	return &MockMetricsRecorder{
-		requests:  make(map[string]int),
-		responses: make(map[string]map[int]int),
+		requests:    make(map[string]int),
+		responses:   make(map[string]map[int]int),
+		missingKeys: make(map[string]int),
 	}
 }
 
+func (m *MockMetricsRecorder) RecordMissingKey(policy string) {
+	m.missingKeys[policy]++
+}
+
 func (m *MockMetricsRecorder) RecordRequest(shard string) {
 	m.requests[shard]++
 }
@@ -140,6 +216,12 @@ func TestProxyHandler_ServeHTTP(t *testing.T) {
 	if mockRecorder.responses[backendServer.URL][200] != 1 {
 		t.Errorf("Expected 1 response recorded, got %d", mockRecorder.responses[backendServer.URL][200])
 	}
+
+	// Verify in-flight tracking was reported to the router
+	if mockRouter.startedRequests[backendServer.URL] != 1 || mockRouter.finishedRequests[backendServer.URL] != 1 {
+		t.Errorf("Expected request start and finish to be reported once, got %d/%d",
+			mockRouter.startedRequests[backendServer.URL], mockRouter.finishedRequests[backendServer.URL])
+	}
 }
 
 func TestProxyHandler_ServeHTTP_InvalidURL(t *testing.T) {
@@ -162,12 +244,12 @@ func TestProxyHandler_ServeHTTP_InvalidURL(t *testing.T) {
 	// Execute
 	handler.ServeHTTP(rr, req)
 
-	// Verify error response - URL parsing fails, so we get BadRequest
-	if rr.Code != http.StatusBadRequest {
-		t.Errorf("Expected status 400, got %d", rr.Code)
+	// Verify error response - a misconfigured shard is a gateway error, not a client one
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", rr.Code)
 	}
 
-	if !strings.Contains(rr.Body.String(), "Invalid target URL") {
+	if !strings.Contains(rr.Body.String(), "Invalid shard URL") {
 		t.Errorf("Expected error message about invalid URL, got '%s'", rr.Body.String())
 	}
 }
@@ -202,6 +284,33 @@ func TestProxyHandler_ServeHTTP_BackendError(t *testing.T) {
 		t.Errorf("Expected 1 request recorded even on error, got %d",
 			mockRecorder.requests["http://non-existent-backend:12345"])
 	}
+
+	// Verify in-flight request was released even on error
+	if mockRouter.finishedRequests["http://non-existent-backend:12345"] != 1 {
+		t.Error("Expected request finish to be reported even on error")
+	}
+}
+
+func TestProxyHandler_ServeHTTP_EmptyHashRing(t *testing.T) {
+	// Todos os shards foram removidos: nenhum shard resolve a chave
+	mockRouter := &MockShardRouter{shardingKey: "user_id"}
+	mockRecorder := NewMockMetricsRecorder()
+	handler := NewProxyHandler(mockRouter, mockRecorder)
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("user_id", "test-user")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "no shard available") {
+		t.Errorf("Expected 'no shard available' error, got '%s'", rr.Body.String())
+	}
+	if len(mockRecorder.requests) != 0 {
+		t.Errorf("Expected no request recorded, got %v", mockRecorder.requests)
+	}
 }
 
 func TestHealthCheckHandler(t *testing.T) {
@@ -215,6 +324,123 @@ func TestHealthCheckHandler(t *testing.T) {
 	}
 }
 
+func TestRingSnapshotHandler(t *testing.T) {
+	mockRouter := &MockShardRouter{shardsAdded: []string{"http://shard01:80", "http://shard02:80"}}
+	handler := RingSnapshotHandler(mockRouter)
+
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/ring", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected JSON response, got %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	snapshot, err := hashring.UnmarshalSnapshot(rr.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(snapshot.Nodes) != 2 {
+		t.Errorf("Expected 2 nodes, got %d", len(snapshot.Nodes))
+	}
+
+	rr = httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/ring?format=binary", nil))
+	if rr.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("Expected binary response, got %s", rr.Header().Get("Content-Type"))
+	}
+	binarySnapshot, err := hashring.UnmarshalSnapshot(rr.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if binarySnapshot.Type != snapshot.Type || len(binarySnapshot.Nodes) != 2 {
+		t.Errorf("Binary snapshot differs from JSON snapshot: %+v", binarySnapshot)
+	}
+}
+
+func TestAdminHandler_RingDoesNotExposeSeed(t *testing.T) {
+	const seed = 0x5eed5eed5eed5eed
+	t.Setenv("HASHING_ALGORITHM", "SIPHASH")
+	t.Setenv("HASHING_SEED", strconv.FormatUint(seed, 10))
+
+	router, err := sharding.NewShardRouter("user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "CONSISTENT", Replicas: 4}); err != nil {
+		t.Fatal(err)
+	}
+	router.AddShard("http://shard01:80")
+
+	rr := httptest.NewRecorder()
+	AdminHandler(router, "").ServeHTTP(rr, httptest.NewRequest("GET", "/ring", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	for _, secret := range []string{strconv.FormatUint(seed, 10), strconv.FormatUint(seed, 16), "hash_seed"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("Snapshot exposes the seed (%s): %s", secret, body)
+		}
+	}
+	snapshot, err := hashring.UnmarshalSnapshot(rr.Body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.SeedFingerprint != hashring.SeedFingerprint(seed) {
+		t.Errorf("Expected seed fingerprint '%s', got '%s'", hashring.SeedFingerprint(seed), snapshot.SeedFingerprint)
+	}
+}
+
+func TestAdminHandler_RemoveShard(t *testing.T) {
+	router, err := sharding.NewShardRouter("user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "CONSISTENT", Replicas: 4}); err != nil {
+		t.Fatal(err)
+	}
+	router.AddShard("http://shard01:80")
+	router.AddShard("http://shard02:80")
+
+	remove := func(handler http.Handler, url, auth string) int {
+		req := httptest.NewRequest("DELETE", "/shards?url="+url, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := remove(AdminHandler(router, ""), "http://shard01:80", "Bearer "); code != http.StatusNotFound {
+		t.Errorf("Expected removal to be disabled without a token, got status %d", code)
+	}
+
+	handler := AdminHandler(router, "s3cr3t")
+	tests := []struct {
+		name     string
+		url      string
+		auth     string
+		expected int
+	}{
+		{name: "missing token", url: "http://shard01:80", expected: http.StatusUnauthorized},
+		{name: "wrong token", url: "http://shard01:80", auth: "Bearer wrong", expected: http.StatusUnauthorized},
+		{name: "unknown shard", url: "http://shard09:80", auth: "Bearer s3cr3t", expected: http.StatusNotFound},
+		{name: "remove shard", url: "http://shard01:80", auth: "Bearer s3cr3t", expected: http.StatusNoContent},
+		{name: "last shard", url: "http://shard02:80", auth: "Bearer s3cr3t", expected: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := remove(handler, tt.url, tt.auth); code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, code)
+			}
+		})
+	}
+
+	if shards := router.ListShards(); len(shards) != 1 || shards[0] != "http://shard02:80" {
+		t.Errorf("Expected only shard02 to remain, got %v", shards)
+	}
+}
+
 func TestProxyHandler_HeaderPropagation(t *testing.T) {
 	// Setup mock backend server that echoes headers
 	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -300,3 +526,310 @@ func TestProxyHandler_HTTPMethods(t *testing.T) {
 		})
 	}
 }
+
+func TestProxyHandler_BodyShardingKey(t *testing.T) {
+	body := `{"customer_id": "cust-1", "items": [1, 2, 3]}`
+	var received string
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer backendServer.Close()
+
+	router, err := sharding.NewShardRouter("json:customer_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "JUMP"}); err != nil {
+		t.Fatal(err)
+	}
+	router.AddShard(backendServer.URL)
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key := router.GetShardingKey(req); key != "cust-1" {
+		t.Errorf("Expected sharding key 'cust-1', got '%s'", key)
+	}
+
+	rr := httptest.NewRecorder()
+	NewProxyHandler(router, NewMockMetricsRecorder()).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("Expected status 201, got %d", rr.Code)
+	}
+	if received != body {
+		t.Errorf("Expected backend to receive the original body, got %q", received)
+	}
+}
+
+func TestProxyHandler_MissingKeyReject(t *testing.T) {
+	mockRouter := &MockShardRouter{
+		shardingKey:      "user_id",
+		missingKeyPolicy: sharding.MissingKeyReject,
+	}
+	mockRecorder := NewMockMetricsRecorder()
+	handler := NewProxyHandler(mockRouter, mockRecorder)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected JSON error, got Content-Type '%s'", rr.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rr.Body.String(), `"error":"missing sharding key"`) {
+		t.Errorf("Unexpected error body: %s", rr.Body.String())
+	}
+	if mockRecorder.missingKeys[sharding.MissingKeyReject] != 1 {
+		t.Errorf("Expected missing key to be recorded for policy reject, got %v", mockRecorder.missingKeys)
+	}
+	if len(mockRecorder.requests) != 0 {
+		t.Errorf("Expected no request to be proxied, got %v", mockRecorder.requests)
+	}
+}
+
+func TestProxyHandler_MissingKeyDefault(t *testing.T) {
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("default shard"))
+	}))
+	defer backendServer.Close()
+
+	mockRouter := &MockShardRouter{
+		shardingKey:      "user_id",
+		expectedShard:    "http://unused:80",
+		missingKeyPolicy: sharding.MissingKeyDefault,
+		missingKeyShards: []string{backendServer.URL},
+	}
+	mockRecorder := NewMockMetricsRecorder()
+	handler := NewProxyHandler(mockRouter, mockRecorder)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+
+	if rr.Body.String() != "default shard" {
+		t.Errorf("Expected request routed to the default shard, got '%s'", rr.Body.String())
+	}
+	if mockRecorder.requests[backendServer.URL] != 1 || mockRecorder.missingKeys[sharding.MissingKeyDefault] != 1 {
+		t.Errorf("Unexpected metrics: requests=%v missing=%v", mockRecorder.requests, mockRecorder.missingKeys)
+	}
+}
+
+func TestProxyHandler_MissingKeyBroadcast(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	newBackend := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			received[name] = string(body)
+			mu.Unlock()
+			w.WriteHeader(status)
+			w.Write([]byte(name))
+		}))
+	}
+	failing := newBackend("failing", http.StatusInternalServerError)
+	defer failing.Close()
+	healthy := newBackend("healthy", http.StatusAccepted)
+	defer healthy.Close()
+
+	mockRouter := &MockShardRouter{
+		shardingKey:      "user_id",
+		missingKeyPolicy: sharding.MissingKeyBroadcast,
+		missingKeyShards: []string{failing.URL, healthy.URL},
+	}
+	mockRecorder := NewMockMetricsRecorder()
+	handler := NewProxyHandler(mockRouter, mockRecorder)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/invalidate", strings.NewReader("payload")))
+
+	if rr.Code != http.StatusAccepted || rr.Body.String() != "healthy" {
+		t.Errorf("Expected the successful shard response, got %d '%s'", rr.Code, rr.Body.String())
+	}
+	if received["failing"] != "payload" || received["healthy"] != "payload" {
+		t.Errorf("Expected body sent to every shard, got %v", received)
+	}
+	for _, shard := range mockRouter.missingKeyShards {
+		if mockRecorder.requests[shard] != 1 {
+			t.Errorf("Expected 1 request recorded for %s, got %d", shard, mockRecorder.requests[shard])
+		}
+		if mockRouter.startedRequests[shard] != 1 || mockRouter.finishedRequests[shard] != 1 {
+			t.Errorf("Expected start and finish reported for %s", shard)
+		}
+	}
+	if mockRecorder.responses[failing.URL][500] != 1 || mockRecorder.responses[healthy.URL][202] != 1 {
+		t.Errorf("Unexpected responses recorded: %v", mockRecorder.responses)
+	}
+}
+
+func TestProxyHandler_MissingKeyBroadcastBodyLimit(t *testing.T) {
+	requests := 0
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	mockRouter := &MockShardRouter{
+		shardingKey:      "user_id",
+		missingKeyPolicy: sharding.MissingKeyBroadcast,
+		missingKeyShards: []string{backendServer.URL},
+	}
+	handler := NewProxyHandlerWithConfig(mockRouter, NewMockMetricsRecorder(), interfaces.ProxyConfig{BroadcastBodyLimit: 4})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/invalidate", strings.NewReader("payload")))
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rr.Code)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no shard to receive the request, got %d requests", requests)
+	}
+}
+
+func TestProxyHandler_PreservesQueryAndForwardedHeaders(t *testing.T) {
+	var received *http.Request
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backendServer.Close()
+
+	mockRouter := &MockShardRouter{
+		shardingKey:   "user_id",
+		expectedShard: backendServer.URL,
+	}
+	handler := NewProxyHandler(mockRouter, NewMockMetricsRecorder())
+
+	req := httptest.NewRequest("GET", "http://router.local/search?q=shard&page=2", nil)
+	req.RemoteAddr = "192.0.2.10:40000"
+	req.Header.Set("user_id", "test-user")
+	req.Header.Set("Connection", "close")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if received == nil {
+		t.Fatal("Expected request to reach the backend")
+	}
+	if received.URL.RawQuery != "q=shard&page=2" {
+		t.Errorf("Expected query string to be forwarded, got '%s'", received.URL.RawQuery)
+	}
+	if received.Header.Get("X-Forwarded-For") != "192.0.2.10" || received.Header.Get("X-Forwarded-Host") != "router.local" {
+		t.Errorf("Unexpected forwarded headers: %v", received.Header)
+	}
+	if req.Header.Get("X-Forwarded-For") != "" {
+		t.Error("Inbound request headers were modified")
+	}
+}
+
+func TestProxyHandler_Upgrade(t *testing.T) {
+	upgradedKey := make(chan string, 1)
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgradedKey <- r.Header.Get("user_id")
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		io.Copy(conn, brw)
+	}))
+	defer backendServer.Close()
+
+	mockRouter := &MockShardRouter{
+		shardingKey:   "user_id",
+		expectedShard: backendServer.URL,
+	}
+	router := httptest.NewServer(NewProxyHandler(mockRouter, NewMockMetricsRecorder()))
+	defer router.Close()
+
+	conn, err := net.Dial("tcp", router.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: router\r\nuser_id: test-user\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+	if key := <-upgradedKey; key != "test-user" {
+		t.Errorf("Expected sharding key header to reach the shard, got '%s'", key)
+	}
+
+	io.WriteString(conn, "ping\n")
+	if line, err := reader.ReadString('\n'); err != nil || line != "ping\n" {
+		t.Errorf("Expected frame to be relayed, got %q (%v)", line, err)
+	}
+}
+
+func TestProxyHandler_GRPCRoutingErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		router     *MockShardRouter
+		key        string
+		grpcStatus string
+	}{
+		{
+			name:       "Missing key rejected",
+			router:     &MockShardRouter{shardingKey: "user_id", missingKeyPolicy: sharding.MissingKeyReject},
+			grpcStatus: "3",
+		},
+		{
+			name:       "No shard available",
+			router:     &MockShardRouter{shardingKey: "user_id", missingKeyPolicy: sharding.MissingKeyRandom},
+			grpcStatus: "14",
+		},
+		{
+			name:       "Empty hash ring",
+			router:     &MockShardRouter{shardingKey: "user_id"},
+			key:        "test-user",
+			grpcStatus: "14",
+		},
+		{
+			name:       "Invalid shard URL",
+			router:     &MockShardRouter{shardingKey: "user_id", expectedShard: "ht!tp://invalid-url"},
+			key:        "test-user",
+			grpcStatus: "14",
+		},
+		{
+			name:       "Shard unreachable",
+			router:     &MockShardRouter{shardingKey: "user_id", expectedShard: "http://127.0.0.1:1"},
+			key:        "test-user",
+			grpcStatus: "14",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyHandler(tt.router, NewMockMetricsRecorder())
+
+			req := httptest.NewRequest("POST", "/orders.v1.Orders/Get", nil)
+			req.Header.Set("Content-Type", "application/grpc")
+			if tt.key != "" {
+				req.Header.Set("user_id", tt.key)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/grpc" {
+				t.Errorf("Expected trailers-only gRPC response, got %d %v", rr.Code, rr.Header())
+			}
+			if status := rr.Header().Get("Grpc-Status"); status != tt.grpcStatus {
+				t.Errorf("Expected grpc-status %s, got '%s'", tt.grpcStatus, status)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// DefaultBodyLimit é o tamanho máximo, em bytes, do corpo lido para extrair a chave
+const DefaultBodyLimit = 1 << 20
+
+// BodyExtractor extrai a chave de sharding de um campo do corpo da requisição.
+// Até Limit bytes são lidos e o corpo é reconstituído para ser enviado ao shard sem
+// alterações; corpos maiores seguem para o shard como requisições sem chave.
+type BodyExtractor struct {
+	Limit int64
+
+	spec   string
+	accept func(mediaType string) bool
+	lookup func(body []byte) (string, bool)
+}
+
+// newBodyExtractorFromEnv cria o extrator do corpo com o limite de SHARDING_BODY_LIMIT
+func newBodyExtractorFromEnv(source, arg string) (*BodyExtractor, error) {
+	limit := int64(DefaultBodyLimit)
+	if v := os.Getenv("SHARDING_BODY_LIMIT"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid SHARDING_BODY_LIMIT '%s'", v)
+		}
+		limit = parsed
+	}
+	return NewBodyExtractor(source, arg, limit)
+}
+
+// NewBodyExtractor cria um extrator do corpo para as fontes:
+//
+//	json:<pointer>       JSON pointer (/customer/id) ou caminho com pontos (customer.id)
+//	form:<campo>         campo de um formulário application/x-www-form-urlencoded
+//	protobuf:<campos>    números dos campos de uma mensagem protobuf (1 ou 2.1 para mensagens aninhadas)
+func NewBodyExtractor(source, arg string, limit int64) (*BodyExtractor, error) {
+	e := &BodyExtractor{Limit: limit, spec: source + ":" + arg}
+
+	switch source {
+	case "json":
+		path, err := jsonPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		e.accept = func(mediaType string) bool {
+			return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+		}
+		e.lookup = func(body []byte) (string, bool) {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			var value any
+			if err := decoder.Decode(&value); err != nil {
+				return "", false
+			}
+			return lookupValue(value, path)
+		}
+	case "form":
+		e.accept = func(mediaType string) bool {
+			return mediaType == "application/x-www-form-urlencoded"
+		}
+		e.lookup = func(body []byte) (string, bool) {
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				return "", false
+			}
+			value := values.Get(arg)
+			return value, value != ""
+		}
+	case "protobuf":
+		fields, err := protobufPath(arg)
+		if err != nil {
+			return nil, err
+		}
+		e.accept = func(mediaType string) bool {
+			return mediaType == "application/x-protobuf" || mediaType == "application/protobuf" ||
+				mediaType == "application/vnd.google.protobuf"
+		}
+		e.lookup = func(body []byte) (string, bool) {
+			return protobufValue(body, fields)
+		}
+	default:
+		return nil, fmt.Errorf("unknown body source '%s'", source)
+	}
+	return e, nil
+}
+
+// Extract lê o corpo até o limite, substituindo r.Body por um leitor que entrega
+// novamente os bytes lidos seguidos do restante do corpo original
+func (e *BodyExtractor) Extract(r *http.Request) (string, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || !e.accept(mediaType) {
+		return "", false
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r.Body, e.Limit+1))
+	r.Body = &replayBody{Reader: io.MultiReader(bytes.NewReader(buf), r.Body), body: r.Body}
+	if err != nil || int64(len(buf)) > e.Limit {
+		return "", false
+	}
+	return e.lookup(buf)
+}
+
+func (e *BodyExtractor) String() string {
+	return e.spec
+}
+
+// replayBody entrega os bytes já lidos seguidos do corpo original, que continua
+// sendo o responsável por liberar a conexão
+type replayBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *replayBody) Close() error {
+	return b.body.Close()
+}
+
+// jsonPath converte um JSON pointer (RFC 6901) ou um caminho com pontos nos seus segmentos
+func jsonPath(arg string) ([]string, error) {
+	if !strings.HasPrefix(arg, "/") {
+		path := strings.Split(arg, ".")
+		for _, part := range path {
+			if part == "" {
+				return nil, fmt.Errorf("invalid JSON path '%s'", arg)
+			}
+		}
+		return path, nil
+	}
+
+	path := strings.Split(arg[1:], "/")
+	for i, part := range path {
+		path[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+	}
+	return path, nil
+}
+
+// lookupValue percorre objetos e arrays pelo caminho e retorna valores string ou numéricos
+func lookupValue(value any, path []string) (string, bool) {
+	for _, part := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[part]
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(v) {
+				return "", false
+			}
+			value = v[index]
+		default:
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, v != ""
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// protobufPath converte "2.1" nos números de campo [2, 1]
+func protobufPath(arg string) ([]protowire.Number, error) {
+	var fields []protowire.Number
+	for _, part := range strings.Split(arg, ".") {
+		n, err := strconv.ParseInt(part, 10, 32)
+		if err != nil || !protowire.Number(n).IsValid() {
+			return nil, fmt.Errorf("invalid protobuf field number '%s'", part)
+		}
+		fields = append(fields, protowire.Number(n))
+	}
+	return fields, nil
+}
+
+// protobufValue decodifica a mensagem no formato wire, sem o schema, seguindo os campos
+// de mensagens aninhadas. Como no protobuf, a última ocorrência de um campo prevalece.
+// Campos length-delimited são retornados como string e os numéricos em decimal.
+func protobufValue(message []byte, fields []protowire.Number) (string, bool) {
+	var value []byte
+	var wireType protowire.Type
+	found := false
+
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return "", false
+		}
+		message = message[n:]
+
+		size := protowire.ConsumeFieldValue(num, typ, message)
+		if size < 0 {
+			return "", false
+		}
+		if num == fields[0] {
+			value, wireType, found = message[:size], typ, true
+		}
+		message = message[size:]
+	}
+	if !found {
+		return "", false
+	}
+
+	if len(fields) > 1 {
+		if wireType != protowire.BytesType {
+			return "", false
+		}
+		nested, _ := protowire.ConsumeBytes(value)
+		return protobufValue(nested, fields[1:])
+	}
+
+	switch wireType {
+	case protowire.BytesType:
+		v, _ := protowire.ConsumeBytes(value)
+		return string(v), len(v) > 0
+	case protowire.VarintType:
+		v, _ := protowire.ConsumeVarint(value)
+		return strconv.FormatUint(v, 10), true
+	case protowire.Fixed32Type:
+		v, _ := protowire.ConsumeFixed32(value)
+		return strconv.FormatUint(uint64(v), 10), true
+	case protowire.Fixed64Type:
+		v, _ := protowire.ConsumeFixed64(value)
+		return strconv.FormatUint(v, 10), true
+	default:
+		return "", false
+	}
+}
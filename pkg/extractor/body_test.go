@@ -0,0 +1,164 @@
+package extractor
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestBodyExtractor_JSON(t *testing.T) {
+	body := `{"customer": {"id": "cust-1", "tags": ["a", "b"], "a/b": "slash"}, "order_id": 42}`
+
+	tests := []struct {
+		spec     string
+		expected string
+		ok       bool
+	}{
+		{"json:/customer/id", "cust-1", true},
+		{"json:customer.id", "cust-1", true},
+		{"json:/customer/tags/1", "b", true},
+		{"json:/customer/a~1b", "slash", true},
+		{"json:order_id", "42", true},
+		{"json:/customer", "", false},
+		{"json:/customer/tags/2", "", false},
+		{"json:/missing", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			extractor, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+			key, ok := extractor.Extract(req)
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, key, ok)
+			}
+
+			// O corpo deve ser entregue ao shard sem alterações
+			replayed, _ := io.ReadAll(req.Body)
+			if string(replayed) != body {
+				t.Errorf("Body not replayed: %q", replayed)
+			}
+		})
+	}
+}
+
+func TestBodyExtractor_Form(t *testing.T) {
+	extractor, err := Parse("form:customer_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader("item=book&customer_id=cust-2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if key, ok := extractor.Extract(req); !ok || key != "cust-2" {
+		t.Errorf("Expected 'cust-2', got (%q, %v)", key, ok)
+	}
+
+	// Content-Type diferente não tem o corpo lido
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader("customer_id=cust-2"))
+	req.Header.Set("Content-Type", "text/plain")
+	if _, ok := extractor.Extract(req); ok {
+		t.Error("Expected no key for text/plain body")
+	}
+}
+
+func TestBodyExtractor_Protobuf(t *testing.T) {
+	// message Order { string id = 1; Customer customer = 2; }  message Customer { uint64 id = 1; string name = 3; }
+	var customer []byte
+	customer = protowire.AppendTag(customer, 1, protowire.VarintType)
+	customer = protowire.AppendVarint(customer, 1234)
+	customer = protowire.AppendTag(customer, 3, protowire.BytesType)
+	customer = protowire.AppendString(customer, "acme")
+
+	var order []byte
+	order = protowire.AppendTag(order, 1, protowire.BytesType)
+	order = protowire.AppendString(order, "order-1")
+	order = protowire.AppendTag(order, 2, protowire.BytesType)
+	order = protowire.AppendBytes(order, customer)
+
+	tests := []struct {
+		spec     string
+		expected string
+		ok       bool
+	}{
+		{"protobuf:1", "order-1", true},
+		{"protobuf:2.1", "1234", true},
+		{"protobuf:2.3", "acme", true},
+		{"protobuf:1.1", "", false},
+		{"protobuf:5", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			extractor, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("POST", "/orders", strings.NewReader(string(order)))
+			req.Header.Set("Content-Type", "application/x-protobuf")
+
+			key, ok := extractor.Extract(req)
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, key, ok)
+			}
+		})
+	}
+
+	extractor, _ := Parse("protobuf:1")
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader("\xff\xff\xff"))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if _, ok := extractor.Extract(req); ok {
+		t.Error("Expected no key for malformed protobuf")
+	}
+}
+
+func TestBodyExtractor_Limit(t *testing.T) {
+	t.Setenv("SHARDING_BODY_LIMIT", "32")
+	extractor, err := Parse("json:customer_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	small := `{"customer_id": "cust-3"}`
+	large := `{"customer_id": "cust-3", "items": ["` + strings.Repeat("x", 64) + `"]}`
+
+	for _, body := range []string{small, large} {
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		key, ok := extractor.Extract(req)
+		if body == small && (!ok || key != "cust-3") {
+			t.Errorf("Expected 'cust-3' for body within limit, got (%q, %v)", key, ok)
+		}
+		if body == large && ok {
+			t.Errorf("Expected no key for body over limit, got %q", key)
+		}
+
+		// Corpos acima do limite também são entregues completos ao shard
+		replayed, _ := io.ReadAll(req.Body)
+		if string(replayed) != body {
+			t.Errorf("Body not replayed: %q", replayed)
+		}
+	}
+
+	t.Setenv("SHARDING_BODY_LIMIT", "-1")
+	if _, err := Parse("json:customer_id"); err == nil {
+		t.Error("Expected error for invalid SHARDING_BODY_LIMIT")
+	}
+}
+
+func TestBodyExtractor_InvalidSpec(t *testing.T) {
+	for _, spec := range []string{"json:a..b", "protobuf:0", "protobuf:1.x", "protobuf:536870912"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected error for spec '%s'", spec)
+		}
+	}
+}
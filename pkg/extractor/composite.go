@@ -0,0 +1,123 @@
+package extractor
+
+import (
+	"app/pkg/interfaces"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompositeExtractor concatena as chaves de várias fontes e literais, como em
+// header:x-region + ":" + header:id_client. A chave só está presente quando
+// todas as fontes estão presentes.
+type CompositeExtractor struct {
+	Parts []interfaces.KeyExtractor
+}
+
+func (e *CompositeExtractor) Extract(r *http.Request) (string, bool) {
+	var key strings.Builder
+	for _, part := range e.Parts {
+		value, ok := part.Extract(r)
+		if !ok {
+			return "", false
+		}
+		key.WriteString(value)
+	}
+	return key.String(), true
+}
+
+func (e *CompositeExtractor) String() string {
+	parts := make([]string, len(e.Parts))
+	for i, part := range e.Parts {
+		parts[i] = part.String()
+	}
+	return strings.Join(parts, " + ")
+}
+
+// FallbackExtractor tenta as fontes em ordem e usa a primeira chave presente,
+// como em header:id_client | cookie:tenant | query:tenant
+type FallbackExtractor struct {
+	Extractors []interfaces.KeyExtractor
+}
+
+func (e *FallbackExtractor) Extract(r *http.Request) (string, bool) {
+	for _, extractor := range e.Extractors {
+		if value, ok := extractor.Extract(r); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (e *FallbackExtractor) String() string {
+	alternatives := make([]string, len(e.Extractors))
+	for i, extractor := range e.Extractors {
+		alternatives[i] = extractor.String()
+	}
+	return strings.Join(alternatives, " | ")
+}
+
+// LiteralExtractor retorna sempre o mesmo valor, usado como separador em chaves compostas
+type LiteralExtractor struct {
+	Value string
+}
+
+func (e *LiteralExtractor) Extract(r *http.Request) (string, bool) {
+	return e.Value, true
+}
+
+func (e *LiteralExtractor) String() string {
+	return strconv.Quote(e.Value)
+}
+
+// parseComposite cria o extrator de uma alternativa, concatenando as partes separadas por " + "
+func parseComposite(spec string) (interfaces.KeyExtractor, error) {
+	var parts []interfaces.KeyExtractor
+	sources := 0
+	for _, term := range splitOutsideQuotes(spec, " + ") {
+		term = strings.TrimSpace(term)
+		if strings.HasPrefix(term, `"`) {
+			value, err := strconv.Unquote(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid literal %s in sharding key", term)
+			}
+			parts = append(parts, &LiteralExtractor{Value: value})
+			continue
+		}
+
+		extractor, err := parseSource(term)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, extractor)
+		sources++
+	}
+
+	if sources == 0 {
+		return nil, fmt.Errorf("sharding key '%s' has no source", strings.TrimSpace(spec))
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return &CompositeExtractor{Parts: parts}, nil
+}
+
+// splitOutsideQuotes divide s nas ocorrências de sep que não estão dentro de literais entre aspas
+func splitOutsideQuotes(s, sep string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quoted && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse_Composite(t *testing.T) {
+	extractor, err := Parse(`header:x-region + ":" + header:id_client`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("x-region", "eu")
+	req.Header.Set("id_client", "tenant-a")
+	if key, ok := extractor.Extract(req); !ok || key != "eu:tenant-a" {
+		t.Errorf("Expected 'eu:tenant-a', got (%q, %v)", key, ok)
+	}
+
+	// Uma parte ausente torna a chave composta ausente
+	req.Header.Del("x-region")
+	if key, ok := extractor.Extract(req); ok {
+		t.Errorf("Expected no key when a part is missing, got %q", key)
+	}
+}
+
+func TestParse_Fallback(t *testing.T) {
+	extractor, err := Parse("header:id_client | cookie:tenant | query:tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(r *http.Request)
+		target   string
+		expected string
+		ok       bool
+	}{
+		{"Header first", func(r *http.Request) {
+			r.Header.Set("id_client", "from-header")
+			r.AddCookie(&http.Cookie{Name: "tenant", Value: "from-cookie"})
+		}, "/?tenant=from-query", "from-header", true},
+		{"Cookie second", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "tenant", Value: "from-cookie"})
+		}, "/?tenant=from-query", "from-cookie", true},
+		{"Query last", func(r *http.Request) {}, "/?tenant=from-query", "from-query", true},
+		{"None present", func(r *http.Request) {}, "/", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			tt.setup(req)
+			key, ok := extractor.Extract(req)
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, key, ok)
+			}
+		})
+	}
+}
+
+func TestParse_CompositeWithFallback(t *testing.T) {
+	// O " + " tem precedência sobre o " | "
+	extractor, err := Parse(`header:x-region + " | " + header:id_client | query:tenant`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := extractor.(*FallbackExtractor); !ok {
+		t.Fatalf("Expected FallbackExtractor, got %T", extractor)
+	}
+	if expected := `header:x-region + " | " + header:id_client | query:tenant`; extractor.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, extractor.String())
+	}
+
+	req := httptest.NewRequest("GET", "/?tenant=tenant-b", nil)
+	req.Header.Set("x-region", "eu")
+	req.Header.Set("id_client", "tenant-a")
+	if key, _ := extractor.Extract(req); key != "eu | tenant-a" {
+		t.Errorf("Expected 'eu | tenant-a', got %q", key)
+	}
+
+	req.Header.Del("id_client")
+	if key, _ := extractor.Extract(req); key != "tenant-b" {
+		t.Errorf("Expected fallback to 'tenant-b', got %q", key)
+	}
+}
+
+func TestParse_CompositeInvalid(t *testing.T) {
+	specs := []string{
+		`"eu" + ":"`,
+		`header:x-region + "unterminated`,
+		`header:id_client |  | query:tenant`,
+		`header:id_client + path:first`,
+	}
+
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected error for spec '%s'", spec)
+		}
+	}
+}
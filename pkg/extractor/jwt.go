@@ -0,0 +1,295 @@
+package extractor
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// JWTKey é uma chave usada para verificar a assinatura dos tokens. ID corresponde
+// ao "kid" do header do token; chaves sem ID são tentadas para qualquer token.
+type JWTKey struct {
+	ID   string
+	HMAC []byte
+	RSA  *rsa.PublicKey
+}
+
+// JWTExtractor extrai a chave de sharding de uma claim do bearer token do header
+// Authorization. Claims aninhadas usam ponto como separador (org.id). Quando há chaves
+// configuradas, a assinatura (HS256/384/512, RS256/384/512 ou PS256/384/512) e as claims
+// exp e nbf são verificadas e tokens inválidos são tratados como requisições sem chave.
+type JWTExtractor struct {
+	claim string
+	path  []string
+	keys  []JWTKey
+}
+
+// NewJWTExtractor cria um extrator para a claim informada. Sem chaves, o payload
+// é lido sem verificar a assinatura, confiando em um gateway anterior ao router.
+func NewJWTExtractor(claim string, keys []JWTKey) (*JWTExtractor, error) {
+	path := strings.Split(claim, ".")
+	for _, part := range path {
+		if part == "" {
+			return nil, fmt.Errorf("invalid JWT claim '%s'", claim)
+		}
+	}
+	return &JWTExtractor{claim: claim, path: path, keys: keys}, nil
+}
+
+// newJWTExtractorFromEnv cria o extrator com as chaves de SHARDING_JWT_JWKS (arquivo JWKS),
+// SHARDING_JWT_HMAC_SECRET e SHARDING_JWT_RSA_PUBLIC_KEY (arquivo PEM)
+func newJWTExtractorFromEnv(claim string) (*JWTExtractor, error) {
+	var keys []JWTKey
+
+	if path := os.Getenv("SHARDING_JWT_JWKS"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SHARDING_JWT_JWKS: %w", err)
+		}
+		jwks, err := ParseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwks...)
+	}
+
+	if secret := os.Getenv("SHARDING_JWT_HMAC_SECRET"); secret != "" {
+		keys = append(keys, JWTKey{HMAC: []byte(secret)})
+	}
+
+	if path := os.Getenv("SHARDING_JWT_RSA_PUBLIC_KEY"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SHARDING_JWT_RSA_PUBLIC_KEY: %w", err)
+		}
+		key, err := ParseRSAPublicKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, JWTKey{RSA: key})
+	}
+
+	if len(keys) == 0 {
+		log.Printf("No JWT verification key configured, claim %s will be read without verifying the signature", claim)
+	}
+	return NewJWTExtractor(claim, keys)
+}
+
+func (e *JWTExtractor) Extract(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	claims, err := e.parse(strings.TrimSpace(token), time.Now())
+	if err != nil {
+		return "", false
+	}
+	return lookupValue(claims, e.path)
+}
+
+func (e *JWTExtractor) String() string {
+	return "jwt:" + e.claim
+}
+
+// parse decodifica o token e, quando há chaves configuradas, verifica assinatura e validade
+func (e *JWTExtractor) parse(token string, now time.Time) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if len(e.keys) == 0 {
+		return claims, nil
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.verify(header.Alg, header.Kid, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	if exp, ok := claims["exp"].(json.Number); ok {
+		if v, err := exp.Float64(); err != nil || now.Unix() >= int64(v) {
+			return nil, errors.New("token expired")
+		}
+	}
+	if nbf, ok := claims["nbf"].(json.Number); ok {
+		if v, err := nbf.Float64(); err != nil || now.Unix() < int64(v) {
+			return nil, errors.New("token not valid yet")
+		}
+	}
+	return claims, nil
+}
+
+// verify confere a assinatura com as chaves compatíveis com o algoritmo e o kid do token
+func (e *JWTExtractor) verify(alg, kid string, signed, signature []byte) error {
+	var hashFunc crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		hashFunc = crypto.SHA256
+	case "384":
+		hashFunc = crypto.SHA384
+	case "512":
+		hashFunc = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported JWT algorithm '%s'", alg)
+	}
+
+	for _, key := range e.keys {
+		if kid != "" && key.ID != "" && key.ID != kid {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(alg, "HS") && key.HMAC != nil:
+			mac := hmac.New(hmacHash(hashFunc), key.HMAC)
+			mac.Write(signed)
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return nil
+			}
+		case strings.HasPrefix(alg, "RS") && key.RSA != nil:
+			digest := hashFunc.New()
+			digest.Write(signed)
+			if rsa.VerifyPKCS1v15(key.RSA, hashFunc, digest.Sum(nil), signature) == nil {
+				return nil
+			}
+		case strings.HasPrefix(alg, "PS") && key.RSA != nil:
+			digest := hashFunc.New()
+			digest.Write(signed)
+			if rsa.VerifyPSS(key.RSA, hashFunc, digest.Sum(nil), signature, nil) == nil {
+				return nil
+			}
+		}
+	}
+	return errors.New("invalid token signature")
+}
+
+// hmacHash retorna o construtor do hash usado pelo HMAC
+func hmacHash(h crypto.Hash) func() hash.Hash {
+	switch h {
+	case crypto.SHA384:
+		return sha512.New384
+	case crypto.SHA512:
+		return sha512.New
+	default:
+		return sha256.New
+	}
+}
+
+// decodeSegment decodifica um segmento base64url do token. Números são mantidos
+// como json.Number para que IDs numéricos grandes não percam precisão.
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return fmt.Errorf("invalid token segment: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// ParseJWKS lê as chaves RSA ("kty": "RSA") e HMAC ("kty": "oct") de um documento JWKS.
+// Chaves de outros tipos são ignoradas.
+func ParseJWKS(data []byte) ([]JWTKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := []JWTKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("invalid RSA key '%s' in JWKS", jwk.Kid)
+			}
+			keys = append(keys, JWTKey{ID: jwk.Kid, RSA: &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}})
+		case "oct":
+			k, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil || len(k) == 0 {
+				return nil, fmt.Errorf("invalid HMAC key '%s' in JWKS", jwk.Kid)
+			}
+			keys = append(keys, JWTKey{ID: jwk.Kid, HMAC: k})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no RSA or HMAC signing keys")
+	}
+	return keys, nil
+}
+
+// ParseRSAPublicKeyPEM lê uma chave pública RSA em PEM (PKIX, PKCS#1 ou certificado X.509)
+func ParseRSAPublicKeyPEM(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in RSA public key")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, errors.New("PEM block does not contain an RSA public key")
+}
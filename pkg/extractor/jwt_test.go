@@ -0,0 +1,229 @@
+package extractor
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// signToken cria um JWT assinado com HS256 ou RS256
+func signToken(t *testing.T, header, claims map[string]any, key any) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func extractToken(e *JWTExtractor, token string) (string, bool) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return e.Extract(req)
+}
+
+func TestJWTExtractor_Claims(t *testing.T) {
+	extractor, err := NewJWTExtractor("org.id", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs256 := map[string]any{"alg": "HS256"}
+
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		expected string
+		ok       bool
+	}{
+		{"Nested string claim", map[string]any{"org": map[string]any{"id": "acme"}}, "acme", true},
+		{"Nested numeric claim", map[string]any{"org": map[string]any{"id": json.Number("9007199254740993")}}, "9007199254740993", true},
+		{"Missing claim", map[string]any{"org": map[string]any{"name": "acme"}}, "", false},
+		{"Claim is not an object", map[string]any{"org": "acme"}, "", false},
+		{"Object claim", map[string]any{"org": map[string]any{"id": map[string]any{}}}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, hs256, tt.claims, []byte("unused"))
+			key, ok := extractToken(extractor, token)
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, key, ok)
+			}
+		})
+	}
+}
+
+func TestJWTExtractor_Authorization(t *testing.T) {
+	extractor, _ := NewJWTExtractor("tenant_id", nil)
+	token := signToken(t, map[string]any{"alg": "HS256"}, map[string]any{"tenant_id": "acme"}, []byte("unused"))
+
+	for _, header := range []string{"", "Basic dXNlcjpwYXNz", "Bearer", "Bearer not-a-token", "Token " + token} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", header)
+		if key, ok := extractor.Extract(req); ok {
+			t.Errorf("Expected no key for Authorization '%s', got '%s'", header, key)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "bearer "+token)
+	if key, ok := extractor.Extract(req); !ok || key != "acme" {
+		t.Errorf("Expected case-insensitive bearer scheme, got (%q, %v)", key, ok)
+	}
+}
+
+func TestJWTExtractor_HMAC(t *testing.T) {
+	secret := []byte("s3cr3t")
+	extractor, _ := NewJWTExtractor("tenant_id", []JWTKey{{HMAC: secret}})
+	hs256 := map[string]any{"alg": "HS256"}
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name   string
+		header map[string]any
+		claims map[string]any
+		key    []byte
+		ok     bool
+	}{
+		{"Valid", hs256, map[string]any{"tenant_id": "acme", "exp": now + 60}, secret, true},
+		{"Wrong secret", hs256, map[string]any{"tenant_id": "acme"}, []byte("other"), false},
+		{"Expired", hs256, map[string]any{"tenant_id": "acme", "exp": now - 60}, secret, false},
+		{"Not valid yet", hs256, map[string]any{"tenant_id": "acme", "nbf": now + 60}, secret, false},
+		{"Algorithm none", map[string]any{"alg": "none"}, map[string]any{"tenant_id": "acme"}, secret, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signToken(t, tt.header, tt.claims, tt.key)
+			key, ok := extractToken(extractor, token)
+			if ok != tt.ok || (ok && key != "acme") {
+				t.Errorf("Expected ok=%v, got (%q, %v)", tt.ok, key, ok)
+			}
+		})
+	}
+
+	// Token sem assinatura não deve ser aceito quando há chaves configuradas
+	unsigned := signToken(t, map[string]any{"alg": "none"}, map[string]any{"tenant_id": "acme"}, nil)
+	if _, ok := extractToken(extractor, unsigned); ok {
+		t.Error("Expected unsigned token to be rejected")
+	}
+}
+
+func TestJWTExtractor_JWKS(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := fmt.Sprintf(`{"keys": [
+		{"kty": "EC", "kid": "ec", "crv": "P-256"},
+		{"kty": "oct", "kid": "hmac", "k": "%s"},
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": "%s", "e": "%s"}
+	]}`,
+		base64.RawURLEncoding.EncodeToString([]byte("s3cr3t")),
+		base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+	)
+	keys, err := ParseJWKS([]byte(jwks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys from JWKS, got %d", len(keys))
+	}
+
+	extractor, _ := NewJWTExtractor("tenant_id", keys)
+	claims := map[string]any{"tenant_id": "acme"}
+
+	tests := []struct {
+		name   string
+		header map[string]any
+		key    any
+		ok     bool
+	}{
+		{"RS256 with kid", map[string]any{"alg": "RS256", "kid": "rsa-1"}, privateKey, true},
+		{"RS256 without kid", map[string]any{"alg": "RS256"}, privateKey, true},
+		{"RS256 with unknown kid", map[string]any{"alg": "RS256", "kid": "rsa-2"}, privateKey, false},
+		{"RS256 signed by another key", map[string]any{"alg": "RS256", "kid": "rsa-1"}, otherKey, false},
+		{"HS256 from JWKS", map[string]any{"alg": "HS256", "kid": "hmac"}, []byte("s3cr3t"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := extractToken(extractor, signToken(t, tt.header, claims, tt.key))
+			if ok != tt.ok || (ok && key != "acme") {
+				t.Errorf("Expected ok=%v, got (%q, %v)", tt.ok, key, ok)
+			}
+		})
+	}
+}
+
+func TestParse_JWTFromEnv(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	path := t.TempDir() + "/public.pem"
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SHARDING_JWT_RSA_PUBLIC_KEY", path)
+
+	extractor, err := Parse("jwt:tenant_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extractor.String() != "jwt:tenant_id" {
+		t.Errorf("Expected 'jwt:tenant_id', got '%s'", extractor.String())
+	}
+
+	token := signToken(t, map[string]any{"alg": "RS256"}, map[string]any{"tenant_id": "acme"}, privateKey)
+	if key, ok := extractToken(extractor.(*JWTExtractor), token); !ok || key != "acme" {
+		t.Errorf("Expected 'acme', got (%q, %v)", key, ok)
+	}
+
+	forged := signToken(t, map[string]any{"alg": "HS256"}, map[string]any{"tenant_id": "acme"}, []byte("guess"))
+	if _, ok := extractToken(extractor.(*JWTExtractor), forged); ok {
+		t.Error("Expected HS256 token to be rejected with only an RSA key configured")
+	}
+
+	if _, err := Parse("jwt:org..id"); err == nil {
+		t.Error("Expected error for invalid claim path")
+	}
+
+	t.Setenv("SHARDING_JWT_RSA_PUBLIC_KEY", t.TempDir()+"/missing.pem")
+	if _, err := Parse("jwt:tenant_id"); err == nil {
+		t.Error("Expected error for missing RSA public key file")
+	}
+}
@@ -0,0 +1,257 @@
+package extractor
+
+import (
+	"app/pkg/interfaces"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parse cria o extrator da chave de sharding a partir da especificação de SHARDING_KEY.
+// Cada fonte tem o formato <fonte>:<argumento>:
+//
+//	header:<nome>          valor do header HTTP
+//	metadata:<chave>       valor da metadata gRPC; chaves -bin são decodificadas de base64
+//	query:<nome>           valor do parâmetro da query string
+//	cookie:<nome>          valor do cookie
+//	path:<índice>          segmento do path, a partir de 0 (/tenants/acme → path:1 = acme)
+//	path:<template>        segmento nomeado em um template, como /tenants/{tenant}/orders
+//	regex:<expressão>      grupo de captura "key" (ou o primeiro grupo) de uma regex aplicada ao path
+//	jwt:<claim>            claim do bearer token do header Authorization (org.id para claims aninhadas)
+//	json:<pointer>         campo de um corpo JSON, como JSON pointer (/customer/id) ou customer.id
+//	form:<campo>           campo de um formulário application/x-www-form-urlencoded
+//	protobuf:<campos>      números dos campos de uma mensagem protobuf (2.1 para mensagens aninhadas)
+//
+// Uma fonte sem prefixo, como id_client, é o nome de um header, mantendo compatível a
+// configuração anterior. Fontes podem ser concatenadas com " + ", junto a literais entre
+// aspas, e encadeadas com " | ", em que a primeira alternativa presente é usada:
+//
+//	header:x-region + ":" + header:id_client | cookie:tenant | query:tenant
+//
+// O " + " tem precedência sobre o " | " e ambos exigem espaços ao redor.
+func Parse(spec string) (interfaces.KeyExtractor, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty sharding key specification")
+	}
+
+	var alternatives []interfaces.KeyExtractor
+	for _, alternative := range splitOutsideQuotes(spec, " | ") {
+		extractor, err := parseComposite(alternative)
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, extractor)
+	}
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+	return &FallbackExtractor{Extractors: alternatives}, nil
+}
+
+// parseSource cria o extrator de uma única fonte
+func parseSource(spec string) (interfaces.KeyExtractor, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty sharding key specification")
+	}
+
+	source, arg, found := strings.Cut(spec, ":")
+	if !found {
+		return &HeaderExtractor{Name: spec}, nil
+	}
+	if arg == "" {
+		return nil, fmt.Errorf("sharding key source '%s' requires an argument", source)
+	}
+
+	switch strings.ToLower(source) {
+	case "header":
+		return &HeaderExtractor{Name: arg}, nil
+	case "metadata":
+		return &MetadataExtractor{Key: strings.ToLower(arg)}, nil
+	case "query":
+		return &QueryExtractor{Name: arg}, nil
+	case "cookie":
+		return &CookieExtractor{Name: arg}, nil
+	case "path":
+		if strings.HasPrefix(arg, "/") {
+			return NewPathTemplateExtractor(arg)
+		}
+		index, err := strconv.Atoi(arg)
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid path segment index '%s'", arg)
+		}
+		return &PathSegmentExtractor{Index: index}, nil
+	case "regex":
+		return NewPathRegexExtractor(arg)
+	case "jwt":
+		return newJWTExtractorFromEnv(arg)
+	case "json", "form", "protobuf":
+		return newBodyExtractorFromEnv(strings.ToLower(source), arg)
+	default:
+		return nil, fmt.Errorf("unknown sharding key source '%s'", source)
+	}
+}
+
+// HeaderExtractor extrai a chave de um header HTTP
+type HeaderExtractor struct {
+	Name string
+}
+
+func (e *HeaderExtractor) Extract(r *http.Request) (string, bool) {
+	value := r.Header.Get(e.Name)
+	return value, value != ""
+}
+
+func (e *HeaderExtractor) String() string {
+	return "header:" + e.Name
+}
+
+// MetadataExtractor extrai a chave da metadata de uma chamada gRPC, transmitida como
+// headers HTTP/2. Valores de chaves binárias (sufixo -bin) são decodificados de base64.
+type MetadataExtractor struct {
+	Key string
+}
+
+func (e *MetadataExtractor) Extract(r *http.Request) (string, bool) {
+	value := r.Header.Get(e.Key)
+	if value == "" || !strings.HasSuffix(e.Key, "-bin") {
+		return value, value != ""
+	}
+	decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return "", false
+	}
+	return string(decoded), len(decoded) > 0
+}
+
+func (e *MetadataExtractor) String() string {
+	return "metadata:" + e.Key
+}
+
+// QueryExtractor extrai a chave de um parâmetro da query string
+type QueryExtractor struct {
+	Name string
+}
+
+func (e *QueryExtractor) Extract(r *http.Request) (string, bool) {
+	value := r.URL.Query().Get(e.Name)
+	return value, value != ""
+}
+
+func (e *QueryExtractor) String() string {
+	return "query:" + e.Name
+}
+
+// CookieExtractor extrai a chave de um cookie
+type CookieExtractor struct {
+	Name string
+}
+
+func (e *CookieExtractor) Extract(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(e.Name)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+func (e *CookieExtractor) String() string {
+	return "cookie:" + e.Name
+}
+
+// PathSegmentExtractor extrai a chave do segmento do path na posição Index,
+// desconsiderando segmentos vazios (barras duplicadas ou no fim do path)
+type PathSegmentExtractor struct {
+	Index int
+}
+
+func (e *PathSegmentExtractor) Extract(r *http.Request) (string, bool) {
+	segment := 0
+	for _, part := range strings.Split(r.URL.Path, "/") {
+		if part == "" {
+			continue
+		}
+		if segment == e.Index {
+			return part, true
+		}
+		segment++
+	}
+	return "", false
+}
+
+func (e *PathSegmentExtractor) String() string {
+	return "path:" + strconv.Itoa(e.Index)
+}
+
+// PathRegexExtractor extrai a chave de um grupo de captura de uma expressão regular
+// aplicada ao path. Templates como /tenants/{tenant}/orders são convertidos para
+// expressões regulares por NewPathTemplateExtractor.
+type PathRegexExtractor struct {
+	spec  string
+	re    *regexp.Regexp
+	group int
+}
+
+// NewPathRegexExtractor cria um extrator que usa o grupo de captura nomeado "key"
+// ou, na ausência dele, o primeiro grupo de captura da expressão
+func NewPathRegexExtractor(expr string) (*PathRegexExtractor, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sharding key regex '%s': %w", expr, err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("sharding key regex '%s' has no capture group", expr)
+	}
+
+	group := re.SubexpIndex("key")
+	if group < 0 {
+		group = 1
+	}
+	return &PathRegexExtractor{spec: "regex:" + expr, re: re, group: group}, nil
+}
+
+// NewPathTemplateExtractor cria um extrator a partir de um template de path com
+// exatamente um segmento nomeado entre chaves, que se torna a chave de sharding.
+// Segmentos * aceitam qualquer valor e o template casa também com os paths abaixo dele:
+// /tenants/{tenant} extrai acme de /tenants/acme e de /tenants/acme/orders/42.
+func NewPathTemplateExtractor(template string) (*PathRegexExtractor, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	placeholders := 0
+	for _, segment := range strings.Split(strings.Trim(template, "/"), "/") {
+		expr.WriteString("/")
+		switch {
+		case segment == "*":
+			expr.WriteString("[^/]+")
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2:
+			placeholders++
+			expr.WriteString("(?P<key>[^/]+)")
+		default:
+			expr.WriteString(regexp.QuoteMeta(segment))
+		}
+	}
+	expr.WriteString("(?:/|$)")
+
+	if placeholders != 1 {
+		return nil, fmt.Errorf("path template '%s' must have exactly one {placeholder}", template)
+	}
+
+	re := regexp.MustCompile(expr.String())
+	return &PathRegexExtractor{spec: "path:" + template, re: re, group: re.SubexpIndex("key")}, nil
+}
+
+func (e *PathRegexExtractor) Extract(r *http.Request) (string, bool) {
+	match := e.re.FindStringSubmatch(r.URL.Path)
+	if match == nil || match[e.group] == "" {
+		return "", false
+	}
+	return match[e.group], true
+}
+
+func (e *PathRegexExtractor) String() string {
+	return e.spec
+}
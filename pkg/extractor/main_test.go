@@ -0,0 +1,203 @@
+package extractor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse_Sources(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		setup    func(r *http.Request)
+		target   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "Bare name is a header",
+			spec:     "id_client",
+			setup:    func(r *http.Request) { r.Header.Set("id_client", "tenant-a") },
+			target:   "/orders",
+			expected: "tenant-a",
+			ok:       true,
+		},
+		{
+			name:     "Header",
+			spec:     "header:X-Tenant",
+			setup:    func(r *http.Request) { r.Header.Set("X-Tenant", "tenant-b") },
+			target:   "/orders",
+			expected: "tenant-b",
+			ok:       true,
+		},
+		{
+			name:   "Missing header",
+			spec:   "header:X-Tenant",
+			target: "/orders",
+		},
+		{
+			name:     "gRPC metadata",
+			spec:     "metadata:X-Tenant-ID",
+			setup:    func(r *http.Request) { r.Header.Set("x-tenant-id", "tenant-g") },
+			target:   "/orders.v1.Orders/Get",
+			expected: "tenant-g",
+			ok:       true,
+		},
+		{
+			name:     "Binary gRPC metadata",
+			spec:     "metadata:tenant-bin",
+			setup:    func(r *http.Request) { r.Header.Set("tenant-bin", "dGVuYW50LWg") },
+			target:   "/orders.v1.Orders/Get",
+			expected: "tenant-h",
+			ok:       true,
+		},
+		{
+			name:   "Invalid binary gRPC metadata",
+			spec:   "metadata:tenant-bin",
+			setup:  func(r *http.Request) { r.Header.Set("tenant-bin", "not base64!") },
+			target: "/orders.v1.Orders/Get",
+		},
+		{
+			name:     "Query",
+			spec:     "query:tenant",
+			target:   "/orders?tenant=tenant-c&page=2",
+			expected: "tenant-c",
+			ok:       true,
+		},
+		{
+			name:   "Missing query parameter",
+			spec:   "query:tenant",
+			target: "/orders?page=2",
+		},
+		{
+			name:     "Cookie",
+			spec:     "cookie:tenant",
+			setup:    func(r *http.Request) { r.AddCookie(&http.Cookie{Name: "tenant", Value: "tenant-d"}) },
+			target:   "/orders",
+			expected: "tenant-d",
+			ok:       true,
+		},
+		{
+			name:   "Missing cookie",
+			spec:   "cookie:tenant",
+			target: "/orders",
+		},
+		{
+			name:     "Path segment",
+			spec:     "path:1",
+			target:   "/tenants/tenant-e/orders",
+			expected: "tenant-e",
+			ok:       true,
+		},
+		{
+			name:     "Path segment ignores empty segments",
+			spec:     "path:1",
+			target:   "//tenants//tenant-e/",
+			expected: "tenant-e",
+			ok:       true,
+		},
+		{
+			name:   "Path segment out of range",
+			spec:   "path:3",
+			target: "/tenants/tenant-e",
+		},
+		{
+			name:     "Path template",
+			spec:     "path:/tenants/{tenant}/orders",
+			target:   "/tenants/tenant-f/orders/42",
+			expected: "tenant-f",
+			ok:       true,
+		},
+		{
+			name:     "Path template with wildcard",
+			spec:     "path:/*/tenants/{tenant}",
+			target:   "/api/tenants/tenant-g",
+			expected: "tenant-g",
+			ok:       true,
+		},
+		{
+			name:   "Path template without match",
+			spec:   "path:/tenants/{tenant}/orders",
+			target: "/tenants/tenant-f/invoices",
+		},
+		{
+			name:     "Regex with named group",
+			spec:     `regex:^/(?:v\d+/)?accounts/(?P<key>\d+)`,
+			target:   "/v2/accounts/1234/users",
+			expected: "1234",
+			ok:       true,
+		},
+		{
+			name:     "Regex with first group",
+			spec:     `regex:/shops/([a-z]+)`,
+			target:   "/shops/acme",
+			expected: "acme",
+			ok:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing '%s': %v", tt.spec, err)
+			}
+
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+
+			key, ok := extractor.Extract(req)
+			if key != tt.expected || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.ok, key, ok)
+			}
+		})
+	}
+}
+
+func TestParse_String(t *testing.T) {
+	tests := map[string]string{
+		"id_client":                    "header:id_client",
+		"HEADER:X-Tenant":              "header:X-Tenant",
+		"query:tenant":                 "query:tenant",
+		"metadata:X-Tenant-ID":         "metadata:x-tenant-id",
+		"cookie:tenant":                "cookie:tenant",
+		"path:2":                       "path:2",
+		"path:/tenants/{tenant}":       "path:/tenants/{tenant}",
+		"regex:^/t/(?P<key>[^/]+)":     "regex:^/t/(?P<key>[^/]+)",
+		"  header:X-Tenant  ":          "header:X-Tenant",
+		"path:/tenants/{tenant}/items": "path:/tenants/{tenant}/items",
+	}
+
+	for spec, expected := range tests {
+		extractor, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing '%s': %v", spec, err)
+		}
+		if extractor.String() != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, extractor.String())
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"header:",
+		"unknown:customer_id",
+		"path:-1",
+		"path:first",
+		"path:/tenants/orders",
+		"path:/regions/{region}/tenants/{tenant}",
+		"regex:/tenants/[a-z",
+		"regex:/tenants/[a-z]+",
+	}
+
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected error for spec '%s'", spec)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAnchorCapacity é o número padrão de buckets do AnchorHash, ou seja,
+// o número máximo de shards que podem estar no anel ao mesmo tempo
+const DefaultAnchorCapacity = 1024
+
+// ErrCapacityExhausted indica que o hash ring já contém o número máximo de nós
+var ErrCapacityExhausted = errors.New("hash ring capacity exhausted")
+
+// AnchorHashRing implementa o AnchorHash (Mendelson et al., 2020).
+// Um conjunto fixo de Capacity buckets (a âncora) contém os buckets em uso; ao remover
+// qualquer shard, apenas as chaves dele são redistribuídas, e o lookup tem custo
+// esperado constante. Diferente do Jump Hash, permite remover shards do meio da lista.
+// Alterações de membros copiam os vetores da âncora, com custo O(Capacity).
+// Implementa a interface interfaces.SnapshotHashRing
+type AnchorHashRing struct {
+	Capacity      int
+	HashAlgorithm string
+	HashSeed      uint64
+	hashFunc      func(string) uint64
+
+	mu    sync.Mutex
+	state atomic.Pointer[anchorState]
+}
+
+// anchorState contém os vetores do AnchorHash e o mapeamento entre buckets e nós.
+// A[b] é zero para buckets em uso e, para buckets removidos, o tamanho do conjunto
+// de trabalho após a remoção. K, L e W são os vetores de sucessores, localização e
+// conjunto de trabalho, e R é a pilha de buckets removidos, cujas primeiras fresh
+// posições são os buckets nunca utilizados.
+type anchorState struct {
+	A, K, L, W []int
+	R          []int
+	N          int
+	fresh      int
+
+	nodes   []string
+	buckets map[string]int
+}
+
+// Garantir que AnchorHashRing implementa a interface SnapshotHashRing
+var _ interfaces.SnapshotHashRing = (*AnchorHashRing)(nil)
+
+// NewAnchorHashRing cria um novo hash ring baseado em AnchorHash com a capacidade informada.
+// Quando capacity é zero ou negativo, utiliza DefaultAnchorCapacity.
+func NewAnchorHashRing(capacity int) interfaces.HashRing {
+	if capacity <= 0 {
+		capacity = DefaultAnchorCapacity
+	}
+
+	ring := &AnchorHashRing{
+		Capacity: capacity,
+	}
+	ring.state.Store(newAnchorState(capacity))
+	ring.hashFunc, ring.HashAlgorithm, ring.HashSeed = resolveHashAlgorithm()
+	return ring
+}
+
+func (ring *AnchorHashRing) GetHashAlgorithm() string {
+	return ring.HashAlgorithm
+}
+
+// AddNode ocupa o último bucket removido com o nó. Como os buckets livres formam uma
+// pilha, readicionar shards na ordem inversa da remoção restaura o posicionamento original.
+// Retorna ErrCapacityExhausted quando todos os Capacity buckets estão em uso.
+func (ring *AnchorHashRing) AddNode(nodeID string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load()
+	if _, ok := current.buckets[nodeID]; ok {
+		return nil
+	}
+	if len(current.R) == 0 {
+		return fmt.Errorf("%w: AnchorHash capacity %d does not fit node %s", ErrCapacityExhausted, ring.Capacity, nodeID)
+	}
+
+	state := current.clone()
+	b := state.addBucket()
+	state.nodes[b] = nodeID
+	state.buckets[nodeID] = b
+	ring.state.Store(state)
+	return nil
+}
+
+// RemoveNode libera o bucket do nó. Apenas as chaves que pertenciam a ele são redistribuídas.
+func (ring *AnchorHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load()
+	b, ok := current.buckets[nodeID]
+	if !ok {
+		return
+	}
+
+	state := current.clone()
+	state.removeBucket(b)
+	state.nodes[b] = ""
+	delete(state.buckets, nodeID)
+	ring.state.Store(state)
+}
+
+// ListNodes retorna os nós ordenados pelo ID
+func (ring *AnchorHashRing) ListNodes() []string {
+	buckets := ring.state.Load().buckets
+	nodes := make([]string, 0, len(buckets))
+	for node := range buckets {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Snapshot exporta os nós com seus buckets e a pilha de buckets removidos, que
+// junto com a capacidade determinam completamente o estado da âncora.
+func (ring *AnchorHashRing) Snapshot() interfaces.RingSnapshot {
+	state := ring.state.Load()
+	nodes := unweightedSnapshotNodes(ring.ListNodes())
+	for i := range nodes {
+		nodes[i].Bucket = state.buckets[nodes[i].ID]
+	}
+
+	return interfaces.RingSnapshot{
+		Type:            string(ANCHOR),
+		HashAlgorithm:   ring.HashAlgorithm,
+		SeedFingerprint: SeedFingerprint(ring.HashSeed),
+		Capacity:        ring.Capacity,
+		Nodes:           nodes,
+		RemovedBuckets:  append([]int{}, state.R[state.fresh:]...),
+	}
+}
+
+// restore reconstrói a âncora a partir dos buckets de um snapshot: ocupa todos os
+// buckets já utilizados, remove os buckets da pilha na ordem original e associa os nós
+func (ring *AnchorHashRing) restore(nodes []interfaces.SnapshotNode, removed []int) error {
+	used := 0
+	for _, node := range nodes {
+		used = max(used, node.Bucket+1)
+	}
+	for _, b := range removed {
+		used = max(used, b+1)
+	}
+	if used > ring.Capacity {
+		return fmt.Errorf("snapshot uses %d buckets but capacity is %d", used, ring.Capacity)
+	}
+
+	state := newAnchorState(ring.Capacity)
+	for i := 0; i < used; i++ {
+		state.addBucket()
+	}
+
+	free := make(map[int]bool, len(removed))
+	for _, b := range removed {
+		if b < 0 || free[b] {
+			return fmt.Errorf("invalid removed bucket %d in snapshot", b)
+		}
+		free[b] = true
+		state.removeBucket(b)
+	}
+
+	for _, node := range nodes {
+		if node.Bucket < 0 || free[node.Bucket] || state.nodes[node.Bucket] != "" {
+			return fmt.Errorf("invalid bucket %d for node %s in snapshot", node.Bucket, node.ID)
+		}
+		state.nodes[node.Bucket] = node.ID
+		state.buckets[node.ID] = node.Bucket
+	}
+	if len(nodes) != state.N {
+		return fmt.Errorf("snapshot has %d nodes but %d buckets in use", len(nodes), state.N)
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.state.Store(state)
+	return nil
+}
+
+// GetNode retorna o node onde o Tenant deverá estar alocado
+func (ring *AnchorHashRing) GetNode(key string) string {
+	state := ring.state.Load()
+	if state.N == 0 {
+		return ""
+	}
+	return state.nodes[state.getBucket(ring.hashFunc(key))]
+}
+
+// GetNodes retorna até n nós distintos em ordem de preferência. Cada posição é o nó
+// que assumiria a chave se os anteriores fossem removidos, calculado sobre uma cópia
+// privada da âncora.
+func (ring *AnchorHashRing) GetNodes(key string, n int) []string {
+	state := ring.state.Load()
+	if n > state.N {
+		n = state.N
+	}
+	if n <= 0 {
+		return []string{}
+	}
+
+	hash := ring.hashFunc(key)
+	nodes := make([]string, 0, n)
+	for {
+		b := state.getBucket(hash)
+		nodes = append(nodes, state.nodes[b])
+		if len(nodes) == n {
+			return nodes
+		}
+		if len(nodes) == 1 {
+			state = state.clone()
+		}
+		state.removeBucket(b)
+	}
+}
+
+// newAnchorState cria a âncora com todos os buckets livres na pilha R,
+// de forma que o primeiro nó adicionado ocupa o bucket 0
+func newAnchorState(capacity int) *anchorState {
+	state := &anchorState{
+		A:       make([]int, capacity),
+		K:       make([]int, capacity),
+		L:       make([]int, capacity),
+		W:       make([]int, capacity),
+		R:       make([]int, 0, capacity),
+		fresh:   capacity,
+		nodes:   make([]string, capacity),
+		buckets: make(map[string]int),
+	}
+	for b := capacity - 1; b >= 0; b-- {
+		state.R = append(state.R, b)
+		state.A[b] = b
+	}
+	for b := 0; b < capacity; b++ {
+		state.K[b], state.L[b], state.W[b] = b, b, b
+	}
+	return state
+}
+
+// clone copia os vetores da âncora para uma alteração copy-on-write
+func (state *anchorState) clone() *anchorState {
+	buckets := make(map[string]int, len(state.buckets))
+	for node, b := range state.buckets {
+		buckets[node] = b
+	}
+	return &anchorState{
+		A:       append([]int(nil), state.A...),
+		K:       append([]int(nil), state.K...),
+		L:       append([]int(nil), state.L...),
+		W:       append([]int(nil), state.W...),
+		R:       append([]int(nil), state.R...),
+		N:       state.N,
+		fresh:   state.fresh,
+		nodes:   append([]string(nil), state.nodes...),
+		buckets: buckets,
+	}
+}
+
+// getBucket retorna o bucket em uso da chave. Enquanto o bucket estiver removido, a chave
+// é re-hasheada entre os buckets que estavam em uso no momento da remoção, seguindo os
+// sucessores K dos buckets removidos depois dele.
+func (state *anchorState) getBucket(hash uint64) int {
+	b := int(hash % uint64(len(state.A)))
+	for state.A[b] > 0 {
+		h := int(anchorMix(hash, b) % uint64(state.A[b]))
+		for state.A[h] >= state.A[b] {
+			h = state.K[h]
+		}
+		b = h
+	}
+	return b
+}
+
+// addBucket retorna à âncora o último bucket removido
+func (state *anchorState) addBucket() int {
+	b := state.R[len(state.R)-1]
+	state.R = state.R[:len(state.R)-1]
+	if len(state.R) < state.fresh {
+		state.fresh = len(state.R)
+	}
+	state.A[b] = 0
+	state.L[state.W[state.N]] = state.N
+	state.W[state.L[b]] = b
+	state.K[b] = b
+	state.N++
+	return b
+}
+
+// removeBucket remove o bucket b do conjunto de trabalho
+func (state *anchorState) removeBucket(b int) {
+	state.R = append(state.R, b)
+	state.N--
+	state.A[b] = state.N
+	state.W[state.L[b]] = state.W[state.N]
+	state.L[state.W[state.N]] = state.L[b]
+	state.K[b] = state.W[state.N]
+}
+
+// anchorMix deriva o hash da chave para o bucket b (splitmix64), evitando recalcular
+// a função de hash configurada a cada passo do lookup
+func anchorMix(hash uint64, b int) uint64 {
+	z := hash + uint64(b+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestAnchorHashRing_DefaultCapacity(t *testing.T) {
+	ring := NewAnchorHashRing(0).(*AnchorHashRing)
+	if ring.Capacity != DefaultAnchorCapacity {
+		t.Errorf("Expected capacity %d, got %d", DefaultAnchorCapacity, ring.Capacity)
+	}
+}
+
+func TestAnchorHashRing_CapacityExhausted(t *testing.T) {
+	ring := NewAnchorHashRing(2)
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+	if err := ring.AddNode("shard03"); !errors.Is(err, ErrCapacityExhausted) {
+		t.Errorf("Expected ErrCapacityExhausted, got %v", err)
+	}
+
+	if nodes := ring.ListNodes(); len(nodes) != 2 {
+		t.Errorf("Expected 2 nodes, got %v", nodes)
+	}
+
+	// Um bucket liberado pode ser ocupado por outro shard
+	ring.RemoveNode("shard01")
+	if err := ring.AddNode("shard03"); err != nil {
+		t.Errorf("Unexpected error after freeing a bucket: %v", err)
+	}
+}
+
+func TestAnchorHashRing_RemoveMiddleShard(t *testing.T) {
+	// Remover um shard do meio só pode mover as chaves que pertenciam a ele
+	ring := NewAnchorHashRing(64)
+	for i := 1; i <= 10; i++ {
+		ring.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+
+	numKeys := 20000
+	before := make(map[string]string, numKeys)
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		before[key] = ring.GetNode(key)
+	}
+
+	ring.RemoveNode("shard07")
+
+	for key, owner := range before {
+		after := ring.GetNode(key)
+		if owner != "shard07" && after != owner {
+			t.Fatalf("Key %s moved from %s to %s", key, owner, after)
+		}
+		if after == "shard07" {
+			t.Fatalf("Key %s still mapped to removed shard", key)
+		}
+	}
+
+	// Readicionar o shard restaura exatamente o posicionamento anterior
+	ring.AddNode("shard07")
+	for key, owner := range before {
+		if after := ring.GetNode(key); after != owner {
+			t.Fatalf("Key %s expected %s after re-adding shard07, got %s", key, owner, after)
+		}
+	}
+}
+
+func TestAnchorHashRing_Distribution(t *testing.T) {
+	ring := NewAnchorHashRing(DefaultAnchorCapacity)
+	numShards := 10
+	for i := 1; i <= numShards; i++ {
+		ring.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+	ring.RemoveNode("shard03")
+	ring.RemoveNode("shard07")
+
+	numKeys := 80000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		distribution[ring.GetNode(fmt.Sprintf("user-%d", i))]++
+	}
+
+	expected := float64(numKeys) / float64(numShards-2)
+	for shard, count := range distribution {
+		if float64(count) < expected*0.9 || float64(count) > expected*1.1 {
+			t.Errorf("Shard %s has %d keys, expected around %.0f", shard, count, expected)
+		}
+	}
+
+	t.Logf("Distribution: %v", distribution)
+}
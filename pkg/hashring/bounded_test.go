@@ -0,0 +1,83 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newBoundedRing(loadFactor float64, shards ...string) *ConsistentHashRing {
+	ring := NewConsistentHashRing(100).(*ConsistentHashRing)
+	ring.LoadFactor = loadFactor
+	for _, shard := range shards {
+		ring.AddNode(shard)
+	}
+	return ring
+}
+
+func TestConsistentHashRing_BoundedLoads_SkipsOverloadedNode(t *testing.T) {
+	ring := newBoundedRing(0.25, "shard01", "shard02", "shard03")
+
+	key := "hot-tenant"
+	owner := ring.GetNode(key)
+
+	// Sem carga, o bounded loads não altera o roteamento
+	unbounded := NewConsistentHashRing(100)
+	for _, shard := range []string{"shard01", "shard02", "shard03"} {
+		unbounded.AddNode(shard)
+	}
+	if owner != unbounded.GetNode(key) {
+		t.Fatalf("Expected idle bounded ring to route like the plain ring, got %s", owner)
+	}
+
+	// Sobrecarregar o dono da chave
+	for i := 0; i < 10; i++ {
+		ring.IncrementLoad(owner)
+	}
+
+	next := ring.GetNode(key)
+	if next == owner {
+		t.Errorf("Expected overloaded shard %s to be skipped", owner)
+	}
+
+	// Ao liberar a carga, a chave volta para o dono original
+	for i := 0; i < 10; i++ {
+		ring.DecrementLoad(owner)
+	}
+	if result := ring.GetNode(key); result != owner {
+		t.Errorf("Expected key to return to %s after load drained, got %s", owner, result)
+	}
+}
+
+func TestConsistentHashRing_BoundedLoads_CapacityRespected(t *testing.T) {
+	shards := []string{"shard01", "shard02", "shard03", "shard04"}
+	ring := newBoundedRing(0.25, shards...)
+
+	// Simula requisições de longa duração que nunca terminam
+	for i := 0; i < 400; i++ {
+		node := ring.GetNode(fmt.Sprintf("tenant-%d", i%7))
+		ring.IncrementLoad(node)
+	}
+
+	capacity := int64(1.25*400/float64(len(shards))) + 1
+	for _, shard := range shards {
+		if load := ring.GetLoad(shard); load > capacity {
+			t.Errorf("Shard %s has load %d, above capacity %d", shard, load, capacity)
+		}
+	}
+}
+
+func TestConsistentHashRing_LoadTracking(t *testing.T) {
+	ring := newBoundedRing(0.25, "shard01")
+
+	// Decrementar sem carga não deve gerar valores negativos
+	ring.DecrementLoad("shard01")
+	if load := ring.GetLoad("shard01"); load != 0 {
+		t.Errorf("Expected load 0, got %d", load)
+	}
+
+	// Nós desconhecidos são ignorados
+	ring.IncrementLoad("unknown")
+	if load := ring.GetLoad("unknown"); load != 0 {
+		t.Errorf("Expected unknown node load 0, got %d", load)
+	}
+}
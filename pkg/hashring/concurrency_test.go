@@ -0,0 +1,84 @@
+package hashring
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestHashRing_ConcurrentLookupAndMutation deve ser executado com -race para validar
+// que lookups concorrentes com alterações de membros não geram data races
+func TestHashRing_ConcurrentLookupAndMutation(t *testing.T) {
+	for name, ring := range membershipRings(t) {
+		t.Run(name, func(t *testing.T) {
+			ring.AddNode("shard01")
+			ring.AddNode("shard02")
+
+			var wg sync.WaitGroup
+			stop := make(chan struct{})
+
+			for r := 0; r < 4; r++ {
+				wg.Add(1)
+				go func(reader int) {
+					defer wg.Done()
+					for i := 0; ; i++ {
+						select {
+						case <-stop:
+							return
+						default:
+						}
+						// shard01 nunca é removido, então o anel nunca fica vazio
+						if node := ring.GetNode(fmt.Sprintf("tenant-%d-%d", reader, i)); node == "" {
+							t.Error("Expected a node during concurrent mutation")
+							return
+						}
+						ring.ListNodes()
+					}
+				}(r)
+			}
+
+			for i := 0; i < 50; i++ {
+				shard := fmt.Sprintf("shard%02d", 3+i%5)
+				ring.AddNode(shard)
+				ring.RemoveNode(shard)
+			}
+
+			close(stop)
+			wg.Wait()
+		})
+	}
+}
+
+func TestConsistentHashRing_ConcurrentLoadTracking(t *testing.T) {
+	ring := newBoundedRing(0.25, "shard01", "shard02", "shard03")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				node := ring.GetNode(fmt.Sprintf("tenant-%d-%d", worker, i))
+				ring.IncrementLoad(node)
+				ring.DecrementLoad(node)
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			ring.AddWeightedNode("shard04", 1+i%2)
+			ring.RemoveNode("shard04")
+		}
+	}()
+
+	wg.Wait()
+
+	for _, shard := range []string{"shard01", "shard02", "shard03"} {
+		if load := ring.GetLoad(shard); load != 0 {
+			t.Errorf("Expected load 0 for %s after all requests finished, got %d", shard, load)
+		}
+	}
+}
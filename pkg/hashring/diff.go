@@ -0,0 +1,89 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"sort"
+	"strconv"
+)
+
+// DefaultDiffSampleSize é o número de chaves sintéticas usadas para estimar a
+// fração do keyspace movida quando nenhuma chave real é informada
+const DefaultDiffSampleSize = 100000
+
+// ShardMovement representa as chaves que passam de um shard para outro
+type ShardMovement struct {
+	From     string
+	To       string
+	Keys     int
+	Fraction float64
+}
+
+// KeyMove representa uma chave que muda de shard
+type KeyMove struct {
+	Key  string
+	From string
+	To   string
+}
+
+// RingDiff é o resultado da comparação entre dois hash rings
+type RingDiff struct {
+	TotalKeys     int
+	MovedKeys     int
+	MovedFraction float64
+	Movements     []ShardMovement
+	Moves         []KeyMove
+}
+
+// DiffRings compara o shard de cada chave antes e depois de uma alteração de capacidade
+// (adição, remoção ou mudança de peso de shards) e agrupa as chaves movidas por par de
+// shards de origem e destino. Quando recordKeys é verdadeiro, as chaves movidas também
+// são retornadas individualmente, na ordem recebida.
+func DiffRings(before, after interfaces.HashRing, keys []string, recordKeys bool) RingDiff {
+	diff := RingDiff{TotalKeys: len(keys)}
+	type pair struct{ from, to string }
+	counts := make(map[pair]int)
+
+	for _, key := range keys {
+		from, to := before.GetNode(key), after.GetNode(key)
+		if from == to {
+			continue
+		}
+		diff.MovedKeys++
+		counts[pair{from, to}]++
+		if recordKeys {
+			diff.Moves = append(diff.Moves, KeyMove{Key: key, From: from, To: to})
+		}
+	}
+
+	for p, count := range counts {
+		diff.Movements = append(diff.Movements, ShardMovement{
+			From:     p.from,
+			To:       p.to,
+			Keys:     count,
+			Fraction: float64(count) / float64(len(keys)),
+		})
+	}
+	sort.Slice(diff.Movements, func(i, j int) bool {
+		if diff.Movements[i].Keys != diff.Movements[j].Keys {
+			return diff.Movements[i].Keys > diff.Movements[j].Keys
+		}
+		if diff.Movements[i].From != diff.Movements[j].From {
+			return diff.Movements[i].From < diff.Movements[j].From
+		}
+		return diff.Movements[i].To < diff.Movements[j].To
+	})
+
+	if len(keys) > 0 {
+		diff.MovedFraction = float64(diff.MovedKeys) / float64(len(keys))
+	}
+	return diff
+}
+
+// SampleKeys gera n chaves sintéticas determinísticas para estimar a fração do keyspace
+func SampleKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "sample-key-" + strconv.Itoa(i)
+	}
+	return keys
+}
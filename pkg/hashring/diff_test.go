@@ -0,0 +1,80 @@
+package hashring
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDiffRings_AddShard(t *testing.T) {
+	before := NewConsistentHashRing(DefaultVirtualNodes)
+	after := NewConsistentHashRing(DefaultVirtualNodes)
+	for i := 1; i <= 3; i++ {
+		before.AddNode(fmt.Sprintf("shard%02d", i))
+		after.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+	after.AddNode("shard04")
+
+	diff := DiffRings(before, after, SampleKeys(DefaultDiffSampleSize), false)
+
+	if diff.TotalKeys != DefaultDiffSampleSize {
+		t.Errorf("Expected %d keys, got %d", DefaultDiffSampleSize, diff.TotalKeys)
+	}
+	if math.Abs(diff.MovedFraction-0.25) > 0.05 {
+		t.Errorf("Expected around 25%% of the keyspace to move, got %.1f%%", diff.MovedFraction*100)
+	}
+	if diff.Moves != nil {
+		t.Errorf("Expected no individual moves without recordKeys, got %d", len(diff.Moves))
+	}
+
+	total := 0
+	for _, movement := range diff.Movements {
+		if movement.To != "shard04" {
+			t.Errorf("Keys should only move to the new shard, got %s -> %s", movement.From, movement.To)
+		}
+		total += movement.Keys
+	}
+	if total != diff.MovedKeys {
+		t.Errorf("Movements account for %d keys, expected %d", total, diff.MovedKeys)
+	}
+
+	// Movimentos ordenados do maior para o menor
+	for i := 1; i < len(diff.Movements); i++ {
+		if diff.Movements[i].Keys > diff.Movements[i-1].Keys {
+			t.Errorf("Movements not sorted by number of keys: %+v", diff.Movements)
+		}
+	}
+}
+
+func TestDiffRings_RecordKeys(t *testing.T) {
+	before := NewRendezvousHashRing()
+	after := NewRendezvousHashRing()
+	before.AddNode("shard01")
+	before.AddNode("shard02")
+	after.AddNode("shard02")
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	diff := DiffRings(before, after, keys, true)
+
+	if len(diff.Moves) != diff.MovedKeys {
+		t.Fatalf("Expected %d recorded moves, got %d", diff.MovedKeys, len(diff.Moves))
+	}
+	for _, move := range diff.Moves {
+		if move.From != "shard01" || move.To != "shard02" {
+			t.Errorf("Unexpected move %+v", move)
+		}
+		if before.GetNode(move.Key) != "shard01" {
+			t.Errorf("Key %s was not on shard01 before", move.Key)
+		}
+	}
+}
+
+func TestDiffRings_NoKeys(t *testing.T) {
+	ring := NewJumpHashRing()
+	ring.AddNode("shard01")
+
+	diff := DiffRings(ring, ring, nil, false)
+	if diff.MovedKeys != 0 || diff.MovedFraction != 0 || len(diff.Movements) != 0 {
+		t.Errorf("Expected empty diff, got %+v", diff)
+	}
+}
@@ -1,6 +1,7 @@
 package hashring
 
 import (
+	"app/pkg/interfaces"
 	"fmt"
 	"os"
 	"testing"
@@ -39,6 +40,36 @@ func TestHashAlgorithmConfiguration(t *testing.T) {
 			envValue:    "MURMUR",
 			expectedLog: "Hash algorithm configured: MURMUR",
 		},
+		{
+			name:        "MURMUR3",
+			envValue:    "MURMUR3",
+			expectedLog: "Hash algorithm configured: MURMUR3",
+		},
+		{
+			name:        "XXHASH64",
+			envValue:    "XXHASH64",
+			expectedLog: "Hash algorithm configured: XXHASH64",
+		},
+		{
+			name:        "FNV1A",
+			envValue:    "FNV1A",
+			expectedLog: "Hash algorithm configured: FNV1A",
+		},
+		{
+			name:        "CRC32",
+			envValue:    "CRC32",
+			expectedLog: "Hash algorithm configured: CRC32",
+		},
+		{
+			name:        "CRC64",
+			envValue:    "CRC64",
+			expectedLog: "Hash algorithm configured: CRC64",
+		},
+		{
+			name:        "SIPHASH",
+			envValue:    "SIPHASH",
+			expectedLog: "Hash algorithm configured: SIPHASH",
+		},
 		{
 			name:        "Default (empty)",
 			envValue:    "",
@@ -84,7 +115,7 @@ func TestHashAlgorithmConfiguration(t *testing.T) {
 
 // TestHashAlgorithmDistribution testa se diferentes algoritmos produzem distribuições diferentes
 func TestHashAlgorithmDistribution(t *testing.T) {
-	algorithms := []string{"SHA512", "SHA256", "MD5", "SHA1", "MURMUR"}
+	algorithms := []string{"SHA512", "SHA256", "MD5", "SHA1", "MURMUR", "XXHASH64", "FNV1A", "CRC32", "CRC64", "SIPHASH"}
 	testKey := "test-key-123"
 
 	results := make(map[string]string)
@@ -124,7 +155,7 @@ func TestHashAlgorithmPerformance(t *testing.T) {
 		t.Skip("Skipping performance test in short mode")
 	}
 
-	algorithms := []string{"SHA512", "SHA256", "SHA1", "MD5", "MURMUR"}
+	algorithms := []string{"SHA512", "SHA256", "SHA1", "MD5", "MURMUR", "XXHASH64", "FNV1A", "CRC32", "CRC64", "SIPHASH"}
 	testKeys := make([]string, 1000)
 
 	// Gerar chaves de teste
@@ -166,9 +197,129 @@ func TestHashAlgorithmPerformance(t *testing.T) {
 	os.Unsetenv("HASHING_ALGORITHM")
 }
 
+// TestNewHashFunc_Seed testa que a seed zero preserva os valores sem seed
+// e que seeds diferentes produzem valores diferentes
+func TestNewHashFunc_Seed(t *testing.T) {
+	unseeded := map[HashAlgorithm]func(string) uint64{
+		MD5:     hashKeyMD5,
+		SHA1:    hashKeySHA1,
+		SHA256:  hashKeySHA256,
+		SHA512:  hashKeySHA512,
+		MURMUR3: func(s string) uint64 { return hashKeyMurmur3(s, 0) },
+		FNV1A:   hashKeyFNV1a,
+	}
+
+	for _, algorithm := range SupportedHashAlgorithms {
+		t.Run(string(algorithm), func(t *testing.T) {
+			zero, err := NewHashFunc(algorithm, 0)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			seeded, _ := NewHashFunc(algorithm, 42)
+			other, _ := NewHashFunc(algorithm, 43)
+
+			key := "tenant-123"
+			if expected, ok := unseeded[algorithm]; ok && zero(key) != expected(key) {
+				t.Errorf("Seed 0 should preserve unseeded hash, got %d, expected %d", zero(key), expected(key))
+			}
+			if zero(key) == seeded(key) {
+				t.Errorf("Seed 42 should change the hash of '%s'", key)
+			}
+			if seeded(key) == other(key) {
+				t.Errorf("Seeds 42 and 43 should produce different hashes for '%s'", key)
+			}
+			if seeded(key) != seeded(key) {
+				t.Errorf("Seeded hash should be deterministic")
+			}
+		})
+	}
+
+	if _, err := NewHashFunc("INVALID", 0); err == nil {
+		t.Error("Expected error for unknown algorithm")
+	}
+}
+
+// TestHashSeedConfiguration testa a leitura de HASHING_SEED
+func TestHashSeedConfiguration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected uint64
+		wantErr  bool
+	}{
+		{value: "", expected: 0},
+		{value: "12345", expected: 12345},
+		{value: "0xff", expected: 255},
+		{value: "invalid", wantErr: true},
+		{value: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("HASHING_SEED", tt.value)
+			seed, err := resolveHashSeed()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for HASHING_SEED '%s'", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if seed != tt.expected {
+				t.Errorf("Expected seed %d, got %d", tt.expected, seed)
+			}
+		})
+	}
+}
+
+// TestNewHashRing_InvalidSeed testa que uma seed inválida falha em vez de ser ignorada
+func TestNewHashRing_InvalidSeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		seed      string
+		wantErr   bool
+	}{
+		{name: "not a number", algorithm: "SIPHASH", seed: "secret", wantErr: true},
+		{name: "64-bit seed with MURMUR3", algorithm: "MURMUR3", seed: "0x100000000", wantErr: true},
+		{name: "64-bit seed with CRC32", algorithm: "CRC32", seed: "0x100000000", wantErr: true},
+		{name: "32-bit seed with MURMUR3", algorithm: "MURMUR3", seed: "0xffffffff"},
+		{name: "64-bit seed with SIPHASH", algorithm: "SIPHASH", seed: "0x9e3779b97f4a7c15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HASHING_ALGORITHM", tt.algorithm)
+			t.Setenv("HASHING_SEED", tt.seed)
+			_, err := NewHashRing(interfaces.HashRingConfig{})
+			if tt.wantErr && err == nil {
+				t.Error("Expected error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestNewHashFunc_SeedWidth testa que MURMUR3 e CRC32 não truncam seeds de 64 bits
+func TestNewHashFunc_SeedWidth(t *testing.T) {
+	for _, algorithm := range SupportedHashAlgorithms {
+		_, err := NewHashFunc(algorithm, 1<<32)
+		narrow := algorithm == MURMUR3 || algorithm == CRC32
+		if narrow && err == nil {
+			t.Errorf("Expected error for %s with a 64-bit seed", algorithm)
+		}
+		if !narrow && err != nil {
+			t.Errorf("Unexpected error for %s: %v", algorithm, err)
+		}
+	}
+}
+
 // Benchmark para comparar performance dos algoritmos
 func BenchmarkHashAlgorithms(b *testing.B) {
-	algorithms := []string{"SHA512", "SHA256", "SHA1", "MD5", "MURMUR"}
+	algorithms := []string{"SHA512", "SHA256", "SHA1", "MD5", "MURMUR", "XXHASH64", "FNV1A", "CRC32", "CRC64", "SIPHASH"}
 
 	for _, algo := range algorithms {
 		b.Run(algo, func(b *testing.B) {
@@ -190,3 +341,14 @@ func BenchmarkHashAlgorithms(b *testing.B) {
 
 	os.Unsetenv("HASHING_ALGORITHM")
 }
+
+// TestHashFunctions_CaseSensitive garante que as funções de hash são byte-exatas;
+// a normalização da chave é responsabilidade do router
+func TestHashFunctions_CaseSensitive(t *testing.T) {
+	for _, algorithm := range SupportedHashAlgorithms {
+		hashFunc, _ := NewHashFunc(algorithm, 0)
+		if hashFunc("Tenant-A") == hashFunc("tenant-a") {
+			t.Errorf("%s: expected different hashes for keys differing only in case", algorithm)
+		}
+	}
+}
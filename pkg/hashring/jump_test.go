@@ -0,0 +1,93 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestJumpHash(t *testing.T) {
+	// Valores de referência da implementação original do paper
+	tests := []struct {
+		key        uint64
+		numBuckets int
+		expected   int32
+	}{
+		{key: 1, numBuckets: 1, expected: 0},
+		{key: 42, numBuckets: 57, expected: 43},
+		{key: 0xDEAD10CC, numBuckets: 1, expected: 0},
+		{key: 0xDEAD10CC, numBuckets: 666, expected: 361},
+		{key: 256, numBuckets: 1024, expected: 520},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d-%d", tt.key, tt.numBuckets), func(t *testing.T) {
+			result := JumpHash(tt.key, tt.numBuckets)
+			if result != tt.expected {
+				t.Errorf("Expected bucket %d, got %d", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestJumpHash_MinimalMovement(t *testing.T) {
+	// Ao adicionar um bucket, as chaves só podem permanecer onde estão ou ir para o novo bucket
+	for key := uint64(0); key < 10000; key++ {
+		for n := 1; n < 20; n++ {
+			before := JumpHash(key, n)
+			after := JumpHash(key, n+1)
+			if before != after && after != int32(n) {
+				t.Fatalf("Key %d moved from bucket %d to %d when growing to %d buckets", key, before, after, n+1)
+			}
+		}
+	}
+}
+
+func TestJumpHashRing_GetNode(t *testing.T) {
+	ring := NewJumpHashRing()
+
+	if node := ring.GetNode("test-key"); node != "" {
+		t.Errorf("Expected empty string for empty ring, got '%s'", node)
+	}
+
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+	ring.AddNode("shard03")
+
+	// Nó duplicado não deve criar um novo bucket
+	ring.AddNode("shard01")
+	if buckets := ring.ListNodes(); len(buckets) != 3 {
+		t.Errorf("Expected 3 buckets, got %d", len(buckets))
+	}
+
+	key := "user123"
+	first := ring.GetNode(key)
+	for i := 0; i < 10; i++ {
+		if result := ring.GetNode(key); result != first {
+			t.Errorf("GetNode should be deterministic. First result: %s, iteration %d result: %s", first, i, result)
+		}
+	}
+}
+
+func TestJumpHashRing_Distribution(t *testing.T) {
+	ring := NewJumpHashRing()
+	shards := []string{"shard01", "shard02", "shard03"}
+	for _, shard := range shards {
+		ring.AddNode(shard)
+	}
+
+	numKeys := 30000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		distribution[ring.GetNode(fmt.Sprintf("user-%d", i))]++
+	}
+
+	expected := float64(numKeys) / float64(len(shards))
+	for _, shard := range shards {
+		count := float64(distribution[shard])
+		if count < expected*0.9 || count > expected*1.1 {
+			t.Errorf("Shard %s has %d keys, expected around %.0f", shard, distribution[shard], expected)
+		}
+	}
+
+	t.Logf("Distribution: %v", distribution)
+}
@@ -0,0 +1,230 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// KetamaPointsPerDigest é o número de pontos extraídos de cada digest MD5
+const KetamaPointsPerDigest = 4
+
+// KetamaDigestsPerServer é o número de digests por servidor com peso médio (160 pontos)
+const KetamaDigestsPerServer = 40
+
+// KetamaHashRing reproduz o layout de pontos da libketama, usado pelas bibliotecas
+// clientes de memcached e Redis: 40 digests MD5 de "host:port-i" por servidor, cada
+// um gerando 4 pontos uint32 little-endian, e chaves posicionadas pelos 4 primeiros
+// bytes do MD5. Assim o router e os serviços que fazem sharding no cliente concordam
+// sobre o dono de cada chave. HASHING_ALGORITHM e HASHING_SEED são ignorados.
+// Implementa as interfaces interfaces.WeightedHashRing e interfaces.SnapshotHashRing
+type KetamaHashRing struct {
+	HashAlgorithm string
+
+	mu    sync.Mutex
+	state atomic.Pointer[ketamaState]
+}
+
+// ketamaState é o snapshot imutável com os pontos ordenados e os pesos dos servidores
+type ketamaState struct {
+	nodes   []Node
+	weights map[string]int
+}
+
+// Garantir que KetamaHashRing implementa as interfaces WeightedHashRing e SnapshotHashRing
+var (
+	_ interfaces.WeightedHashRing = (*KetamaHashRing)(nil)
+	_ interfaces.SnapshotHashRing = (*KetamaHashRing)(nil)
+)
+
+// NewKetamaHashRing cria um novo hash ring compatível com a libketama.
+func NewKetamaHashRing() interfaces.HashRing {
+	if algorithm := strings.ToUpper(os.Getenv("HASHING_ALGORITHM")); algorithm != "" && HashAlgorithm(algorithm) != MD5 {
+		log.Printf("KETAMA hash ring always uses MD5, ignoring HASHING_ALGORITHM=%s", algorithm)
+	}
+
+	ring := &KetamaHashRing{
+		HashAlgorithm: string(MD5),
+	}
+	ring.state.Store(&ketamaState{
+		nodes:   []Node{},
+		weights: make(map[string]int),
+	})
+	return ring
+}
+
+func (ring *KetamaHashRing) GetHashAlgorithm() string {
+	return ring.HashAlgorithm
+}
+
+// AddNode adiciona um servidor com peso 1
+func (ring *KetamaHashRing) AddNode(nodeID string) error {
+	return ring.AddWeightedNode(nodeID, 1)
+}
+
+// AddWeightedNode adiciona ou atualiza um servidor com o peso informado. Assim como na
+// libketama, o número de pontos de cada servidor depende da sua fração do peso total,
+// portanto o anel inteiro é recalculado. Com todos os pesos iguais, cada servidor tem 160 pontos.
+func (ring *KetamaHashRing) AddWeightedNode(nodeID string, weight int) error {
+	if weight < 1 {
+		weight = 1
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	weights := make(map[string]int, len(ring.state.Load().weights)+1)
+	for node, w := range ring.state.Load().weights {
+		weights[node] = w
+	}
+	weights[nodeID] = weight
+	ring.state.Store(&ketamaState{nodes: ketamaPoints(weights), weights: weights})
+	return nil
+}
+
+// RemoveNode remove o servidor e recalcula o anel
+func (ring *KetamaHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	weights := make(map[string]int, len(ring.state.Load().weights))
+	for node, w := range ring.state.Load().weights {
+		if node != nodeID {
+			weights[node] = w
+		}
+	}
+	ring.state.Store(&ketamaState{nodes: ketamaPoints(weights), weights: weights})
+}
+
+// ListNodes retorna os servidores ordenados pelo ID
+func (ring *KetamaHashRing) ListNodes() []string {
+	weights := ring.state.Load().weights
+	nodes := make([]string, 0, len(weights))
+	for node := range weights {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Snapshot exporta os servidores com seus pesos e pontos no anel.
+func (ring *KetamaHashRing) Snapshot() interfaces.RingSnapshot {
+	state := ring.state.Load()
+	hashes := groupHashes(state.nodes)
+
+	nodes := make([]interfaces.SnapshotNode, 0, len(state.weights))
+	for _, nodeID := range ring.ListNodes() {
+		nodes = append(nodes, interfaces.SnapshotNode{
+			ID:     nodeID,
+			Weight: state.weights[nodeID],
+			Hashes: hashes[nodeID],
+		})
+	}
+
+	return interfaces.RingSnapshot{
+		Type:          string(KETAMA),
+		HashAlgorithm: ring.HashAlgorithm,
+		Nodes:         nodes,
+	}
+}
+
+// GetNode retorna o servidor do primeiro ponto com hash maior ou igual ao da chave
+func (ring *KetamaHashRing) GetNode(key string) string {
+	state := ring.state.Load()
+	if len(state.nodes) == 0 {
+		return ""
+	}
+
+	idx := ketamaSearch(state.nodes, ketamaHash(key))
+	return state.nodes[idx].ID
+}
+
+// GetNodes retorna até n servidores distintos percorrendo o anel no sentido horário
+func (ring *KetamaHashRing) GetNodes(key string, n int) []string {
+	state := ring.state.Load()
+	if n > len(state.weights) {
+		n = len(state.weights)
+	}
+	if n <= 0 || len(state.nodes) == 0 {
+		return []string{}
+	}
+
+	idx := ketamaSearch(state.nodes, ketamaHash(key))
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i := 0; i < len(state.nodes) && len(nodes) < n; i++ {
+		node := state.nodes[(idx+i)%len(state.nodes)]
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			nodes = append(nodes, node.ID)
+		}
+	}
+	return nodes
+}
+
+// ketamaPoints calcula os pontos de todos os servidores, ordenados por hash.
+// O número de digests segue a fórmula da libketama: floorf(pct * 40 * servidores).
+func ketamaPoints(weights map[string]int) []Node {
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	nodes := []Node{}
+	for nodeID, weight := range weights {
+		pct := float32(weight) / float32(total)
+		digests := int(math.Floor(float64(float32(float64(pct) * KetamaDigestsPerServer * float64(float32(len(weights)))))))
+		address := ketamaAddress(nodeID)
+		for k := 0; k < digests; k++ {
+			digest := md5.Sum([]byte(fmt.Sprintf("%s-%d", address, k)))
+			for h := 0; h < KetamaPointsPerDigest; h++ {
+				point := binary.LittleEndian.Uint32(digest[h*4:])
+				nodes = append(nodes, Node{ID: nodeID, Hash: uint64(point)})
+			}
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Hash == nodes[j].Hash {
+			return nodes[i].ID < nodes[j].ID
+		}
+		return nodes[i].Hash < nodes[j].Hash
+	})
+	return nodes
+}
+
+// ketamaAddress retorna o "host:port" usado para nomear os pontos do servidor.
+// Node IDs no formato de URL (http://shard01:80) usam apenas o host e a porta.
+func ketamaAddress(nodeID string) string {
+	if u, err := url.Parse(nodeID); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return nodeID
+}
+
+// ketamaHash posiciona a chave no anel com os 4 primeiros bytes do MD5 em little-endian
+func ketamaHash(key string) uint64 {
+	digest := md5.Sum([]byte(key))
+	return uint64(binary.LittleEndian.Uint32(digest[:4]))
+}
+
+// ketamaSearch retorna o índice do primeiro ponto com hash maior ou igual ao informado,
+// voltando ao início do anel quando o hash é maior que todos os pontos
+func ketamaSearch(nodes []Node, hash uint64) int {
+	idx := sort.Search(len(nodes), func(i int) bool {
+		return nodes[i].Hash >= hash
+	})
+	if idx == len(nodes) {
+		idx = 0
+	}
+	return idx
+}
@@ -0,0 +1,73 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestKetamaHashRing_ReferenceLayout(t *testing.T) {
+	// Valores calculados com o layout da libketama: MD5 de "host:port-i",
+	// 4 pontos little-endian por digest e 40 digests por servidor
+	ring := NewKetamaHashRing().(*KetamaHashRing)
+	for _, server := range []string{"10.0.1.1:11211", "10.0.1.2:11211", "10.0.1.3:11211"} {
+		ring.AddNode(server)
+	}
+
+	points := ring.state.Load().nodes
+	if len(points) != 480 {
+		t.Fatalf("Expected 480 points, got %d", len(points))
+	}
+	if points[0].Hash != 4826654 || points[0].ID != "10.0.1.2:11211" {
+		t.Errorf("Unexpected first point: %+v", points[0])
+	}
+	if points[len(points)-1].Hash != 4284233799 || points[len(points)-1].ID != "10.0.1.2:11211" {
+		t.Errorf("Unexpected last point: %+v", points[len(points)-1])
+	}
+
+	expected := map[string]string{
+		"user:1":      "10.0.1.1:11211",
+		"user:2":      "10.0.1.3:11211",
+		"session:abc": "10.0.1.2:11211",
+		"foo":         "10.0.1.2:11211",
+		"bar":         "10.0.1.1:11211",
+		"tenant-42":   "10.0.1.1:11211",
+	}
+	for key, server := range expected {
+		if node := ring.GetNode(key); node != server {
+			t.Errorf("Key %s: expected %s, got %s", key, server, node)
+		}
+	}
+}
+
+func TestKetamaHashRing_URLNodeIDs(t *testing.T) {
+	// Node IDs em formato de URL devem ter o mesmo posicionamento de "host:port"
+	byAddress := NewKetamaHashRing()
+	byURL := NewKetamaHashRing()
+	for i := 1; i <= 3; i++ {
+		byAddress.AddNode(fmt.Sprintf("shard%02d:80", i))
+		byURL.AddNode(fmt.Sprintf("http://shard%02d:80", i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if "http://"+byAddress.GetNode(key) != byURL.GetNode(key) {
+			t.Fatalf("Key %s mapped to %s and %s", key, byAddress.GetNode(key), byURL.GetNode(key))
+		}
+	}
+}
+
+func TestKetamaHashRing_Weights(t *testing.T) {
+	ring := NewKetamaHashRing().(*KetamaHashRing)
+	ring.AddWeightedNode("shard01:80", 1)
+	ring.AddWeightedNode("shard02:80", 3)
+
+	points := make(map[string]int)
+	for _, node := range ring.state.Load().nodes {
+		points[node.ID]++
+	}
+
+	// floorf(pct * 40 * 2) digests de 4 pontos cada
+	if points["shard01:80"] != 80 || points["shard02:80"] != 240 {
+		t.Errorf("Unexpected points per server: %v", points)
+	}
+}
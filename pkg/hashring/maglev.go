@@ -0,0 +1,186 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaglevTableSize é o tamanho padrão da tabela de lookup do Maglev.
+// Deve ser um número primo bem maior que o número de shards.
+const DefaultMaglevTableSize = 65537
+
+// MaglevHashRing implementa o Maglev Hashing (Eisenbud et al., 2016).
+// Cada nó preenche uma tabela de lookup de tamanho primo seguindo sua própria
+// permutação, o que garante lookup O(1) e disrupção limitada quando a lista de nós muda.
+// Implementa a interface interfaces.SnapshotHashRing
+type MaglevHashRing struct {
+	TableSize     int
+	HashAlgorithm string
+	HashSeed      uint64
+	hashFunc      func(string) uint64
+
+	mu    sync.Mutex
+	state atomic.Pointer[maglevState]
+}
+
+// maglevState é o snapshot imutável com os nós ordenados e a tabela de lookup
+type maglevState struct {
+	nodes []string
+	table []int
+}
+
+// Garantir que MaglevHashRing implementa a interface SnapshotHashRing
+var _ interfaces.SnapshotHashRing = (*MaglevHashRing)(nil)
+
+// NewMaglevHashRing cria um novo hash ring baseado em Maglev com a tabela do tamanho informado.
+// Quando tableSize é zero, utiliza DefaultMaglevTableSize.
+func NewMaglevHashRing(tableSize int) (interfaces.HashRing, error) {
+	if tableSize == 0 {
+		tableSize = DefaultMaglevTableSize
+	}
+	if tableSize < 2 || !big.NewInt(int64(tableSize)).ProbablyPrime(0) {
+		return nil, fmt.Errorf("maglev table size must be a prime number, got %d", tableSize)
+	}
+
+	ring := &MaglevHashRing{
+		TableSize: tableSize,
+	}
+	ring.state.Store(&maglevState{nodes: []string{}})
+	ring.hashFunc, ring.HashAlgorithm, ring.HashSeed = resolveHashAlgorithm()
+	return ring, nil
+}
+
+func (ring *MaglevHashRing) GetHashAlgorithm() string {
+	return ring.HashAlgorithm
+}
+
+// AddNode adiciona um nó e reconstrói a tabela de lookup
+func (ring *MaglevHashRing) AddNode(nodeID string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load().nodes
+	for _, node := range current {
+		if node == nodeID {
+			return nil
+		}
+	}
+
+	nodes := make([]string, len(current), len(current)+1)
+	copy(nodes, current)
+	nodes = append(nodes, nodeID)
+	sort.Strings(nodes)
+	ring.state.Store(&maglevState{nodes: nodes, table: ring.populate(nodes)})
+	return nil
+}
+
+// RemoveNode remove o nó e reconstrói a tabela de lookup
+func (ring *MaglevHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load().nodes
+	nodes := make([]string, 0, len(current))
+	for _, node := range current {
+		if node != nodeID {
+			nodes = append(nodes, node)
+		}
+	}
+
+	var table []int
+	if len(nodes) > 0 {
+		table = ring.populate(nodes)
+	}
+	ring.state.Store(&maglevState{nodes: nodes, table: table})
+}
+
+// ListNodes retorna os nós ordenados pelo ID
+func (ring *MaglevHashRing) ListNodes() []string {
+	current := ring.state.Load().nodes
+	nodes := make([]string, len(current))
+	copy(nodes, current)
+	return nodes
+}
+
+// Snapshot exporta os nós e o tamanho da tabela, suficientes para reconstruí-la.
+func (ring *MaglevHashRing) Snapshot() interfaces.RingSnapshot {
+	return interfaces.RingSnapshot{
+		Type:            string(MAGLEV),
+		HashAlgorithm:   ring.HashAlgorithm,
+		SeedFingerprint: SeedFingerprint(ring.HashSeed),
+		TableSize:       ring.TableSize,
+		Nodes:           unweightedSnapshotNodes(ring.ListNodes()),
+	}
+}
+
+// GetNode retorna o node onde o Tenant deverá estar alocado
+func (ring *MaglevHashRing) GetNode(key string) string {
+	state := ring.state.Load()
+	if len(state.nodes) == 0 {
+		return ""
+	}
+	return state.nodes[state.table[ring.hashFunc(key)%uint64(ring.TableSize)]]
+}
+
+// GetNodes retorna até n nós distintos percorrendo a tabela de lookup
+// a partir da posição da chave
+func (ring *MaglevHashRing) GetNodes(key string, n int) []string {
+	state := ring.state.Load()
+	if n > len(state.nodes) {
+		n = len(state.nodes)
+	}
+	if n <= 0 {
+		return []string{}
+	}
+
+	start := int(ring.hashFunc(key) % uint64(ring.TableSize))
+	nodes := make([]string, 0, n)
+	seen := make(map[int]bool, n)
+	for i := 0; i < len(state.table) && len(nodes) < n; i++ {
+		entry := state.table[(start+i)%len(state.table)]
+		if !seen[entry] {
+			seen[entry] = true
+			nodes = append(nodes, state.nodes[entry])
+		}
+	}
+	return nodes
+}
+
+// populate preenche a tabela de lookup alternando entre os nós, onde cada nó
+// ocupa a próxima posição livre da sua permutação (offset + j*skip) mod M.
+func (ring *MaglevHashRing) populate(nodes []string) []int {
+	size := uint64(ring.TableSize)
+	offsets := make([]uint64, len(nodes))
+	skips := make([]uint64, len(nodes))
+	for i, node := range nodes {
+		offsets[i] = ring.hashFunc(node) % size
+		skips[i] = ring.hashFunc(node+"-skip")%(size-1) + 1
+	}
+
+	table := make([]int, size)
+	for i := range table {
+		table[i] = -1
+	}
+
+	next := make([]uint64, len(nodes))
+	filled := uint64(0)
+	for {
+		for i := range nodes {
+			c := (offsets[i] + next[i]*skips[i]) % size
+			for table[c] >= 0 {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % size
+			}
+			table[c] = i
+			next[i]++
+			filled++
+			if filled == size {
+				return table
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewMaglevHashRing(t *testing.T) {
+	tests := []struct {
+		name        string
+		tableSize   int
+		expected    int
+		expectError bool
+	}{
+		{name: "Default table size", tableSize: 0, expected: DefaultMaglevTableSize},
+		{name: "Prime table size", tableSize: 5003, expected: 5003},
+		{name: "Non-prime table size", tableSize: 5000, expectError: true},
+		{name: "Negative table size", tableSize: -7, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewMaglevHashRing(tt.tableSize)
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if size := ring.(*MaglevHashRing).TableSize; size != tt.expected {
+				t.Errorf("Expected table size %d, got %d", tt.expected, size)
+			}
+		})
+	}
+}
+
+func TestMaglevHashRing_GetNode(t *testing.T) {
+	ring, err := NewMaglevHashRing(5003)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if node := ring.GetNode("test-key"); node != "" {
+		t.Errorf("Expected empty string for empty ring, got '%s'", node)
+	}
+
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+	ring.AddNode("shard03")
+
+	key := "user123"
+	first := ring.GetNode(key)
+	if first == "" {
+		t.Fatal("Expected a valid shard")
+	}
+
+	for i := 0; i < 10; i++ {
+		if result := ring.GetNode(key); result != first {
+			t.Errorf("GetNode should be deterministic. First result: %s, iteration %d result: %s", first, i, result)
+		}
+	}
+}
+
+func TestMaglevHashRing_TableBalance(t *testing.T) {
+	ring, _ := NewMaglevHashRing(5003)
+	shards := []string{"shard01", "shard02", "shard03", "shard04", "shard05"}
+	for _, shard := range shards {
+		ring.AddNode(shard)
+	}
+
+	// Cada nó ocupa uma posição por rodada, então a diferença entre nós é no máximo 1
+	counts := make(map[int]int)
+	for _, entry := range ring.(*MaglevHashRing).state.Load().table {
+		if entry < 0 {
+			t.Fatal("Lookup table has unfilled entries")
+		}
+		counts[entry]++
+	}
+
+	min, max := len(ring.(*MaglevHashRing).state.Load().table), 0
+	for _, count := range counts {
+		if count < min {
+			min = count
+		}
+		if count > max {
+			max = count
+		}
+	}
+
+	if len(counts) != len(shards) || max-min > 1 {
+		t.Errorf("Expected balanced table across %d shards, got %v", len(shards), counts)
+	}
+}
+
+func TestMaglevHashRing_InsertionOrderIndependent(t *testing.T) {
+	a, _ := NewMaglevHashRing(5003)
+	b, _ := NewMaglevHashRing(5003)
+	for _, shard := range []string{"shard01", "shard02", "shard03"} {
+		a.AddNode(shard)
+	}
+	for _, shard := range []string{"shard03", "shard01", "shard02"} {
+		b.AddNode(shard)
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("tenant-%d", i)
+		if a.GetNode(key) != b.GetNode(key) {
+			t.Fatalf("Key %s mapped differently depending on insertion order", key)
+		}
+	}
+}
+
+func TestMaglevHashRing_BoundedDisruption(t *testing.T) {
+	before, _ := NewMaglevHashRing(65537)
+	after, _ := NewMaglevHashRing(65537)
+	for i := 1; i <= 10; i++ {
+		shard := fmt.Sprintf("shard%02d", i)
+		before.AddNode(shard)
+		if i != 5 {
+			after.AddNode(shard)
+		}
+	}
+
+	numKeys := 20000
+	moved := 0
+	for i := 0; i < numKeys; i++ {
+		key := fmt.Sprintf("tenant-%d", i)
+		previous := before.GetNode(key)
+		if previous != "shard05" && previous != after.GetNode(key) {
+			moved++
+		}
+	}
+
+	// Chaves de shards que permaneceram devem praticamente não se mover
+	if ratio := float64(moved) / float64(numKeys); ratio > 0.05 {
+		t.Errorf("Expected less than 5%% of unrelated keys to move, got %.2f%%", ratio*100)
+	}
+}
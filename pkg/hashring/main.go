@@ -7,12 +7,23 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
 	"log"
+	"math"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/cespare/xxhash/v2"
+	"github.com/dchest/siphash"
 	"github.com/spaolacci/murmur3"
 )
 
@@ -20,36 +31,150 @@ import (
 type HashAlgorithm string
 
 const (
-	MD5     HashAlgorithm = "MD5"
-	SHA1    HashAlgorithm = "SHA1"
-	SHA256  HashAlgorithm = "SHA256"
-	SHA512  HashAlgorithm = "SHA512"
-	MURMUR3 HashAlgorithm = "MURMUR3"
+	MD5      HashAlgorithm = "MD5"
+	SHA1     HashAlgorithm = "SHA1"
+	SHA256   HashAlgorithm = "SHA256"
+	SHA512   HashAlgorithm = "SHA512"
+	MURMUR3  HashAlgorithm = "MURMUR3"
+	XXHASH64 HashAlgorithm = "XXHASH64"
+	FNV1A    HashAlgorithm = "FNV1A"
+	CRC32    HashAlgorithm = "CRC32"
+	CRC64    HashAlgorithm = "CRC64"
+	SIPHASH  HashAlgorithm = "SIPHASH"
 )
 
+// SupportedHashAlgorithms lista os algoritmos de hash aceitos em HASHING_ALGORITHM
+var SupportedHashAlgorithms = []HashAlgorithm{
+	SHA512, SHA256, SHA1, MD5, MURMUR3, XXHASH64, FNV1A, CRC32, CRC64, SIPHASH,
+}
+
+// RingType define as implementações de hash ring disponíveis
+type RingType string
+
+const (
+	CONSISTENT RingType = "CONSISTENT"
+	JUMP       RingType = "JUMP"
+	RENDEZVOUS RingType = "RENDEZVOUS"
+	MAGLEV     RingType = "MAGLEV"
+	KETAMA     RingType = "KETAMA"
+	MULTIPROBE RingType = "MULTIPROBE"
+	ANCHOR     RingType = "ANCHOR"
+)
+
+// DefaultVirtualNodes é o número padrão de réplicas virtuais por shard no ConsistentHashRing.
+// O valor é fixo e independe do número de shards, de forma que adicionar um shard
+// move apenas ~1/N das chaves, todas para o novo shard.
+const DefaultVirtualNodes = 160
+
+// NewHashRing cria o hash ring correspondente ao tipo configurado.
+// Quando nenhum tipo é informado, utiliza o ConsistentHashRing.
+// Uma HASHING_SEED inválida ou incompatível com HASHING_ALGORITHM retorna erro.
+func NewHashRing(config interfaces.HashRingConfig) (interfaces.HashRing, error) {
+	if err := checkHashSeed(); err != nil {
+		return nil, err
+	}
+	return newHashRing(config)
+}
+
+// newHashRing cria o hash ring do tipo configurado sem validar a seed do ambiente
+func newHashRing(config interfaces.HashRingConfig) (interfaces.HashRing, error) {
+	ringType := RingType(strings.ToUpper(config.Type))
+	if ringType == "" {
+		ringType = CONSISTENT
+	}
+
+	if config.Replicas <= 0 {
+		config.Replicas = DefaultVirtualNodes
+	}
+
+	if config.LoadFactor > 0 && ringType != CONSISTENT {
+		log.Printf("Bounded loads are only supported by the CONSISTENT hash ring, ignoring load factor")
+	}
+
+	switch ringType {
+	case CONSISTENT:
+		log.Printf("Hash ring type configured: CONSISTENT with %d virtual nodes per shard", config.Replicas)
+		ring := NewConsistentHashRing(config.Replicas).(*ConsistentHashRing)
+		if config.LoadFactor > 0 {
+			ring.LoadFactor = config.LoadFactor
+			log.Printf("Bounded loads enabled with load factor %.2f", config.LoadFactor)
+		}
+		return ring, nil
+	case JUMP:
+		log.Printf("Hash ring type configured: JUMP")
+		return NewJumpHashRing(), nil
+	case RENDEZVOUS:
+		log.Printf("Hash ring type configured: RENDEZVOUS")
+		return NewRendezvousHashRing(), nil
+	case MAGLEV:
+		log.Printf("Hash ring type configured: MAGLEV")
+		return NewMaglevHashRing(config.TableSize)
+	case KETAMA:
+		log.Printf("Hash ring type configured: KETAMA")
+		return NewKetamaHashRing(), nil
+	case MULTIPROBE:
+		ring := NewMultiProbeHashRing(config.Probes).(*MultiProbeHashRing)
+		log.Printf("Hash ring type configured: MULTIPROBE with %d probes per key", ring.Probes)
+		return ring, nil
+	case ANCHOR:
+		ring := NewAnchorHashRing(config.Capacity).(*AnchorHashRing)
+		log.Printf("Hash ring type configured: ANCHOR with capacity for %d shards", ring.Capacity)
+		return ring, nil
+	default:
+		return nil, fmt.Errorf("unknown hash ring type '%s'", config.Type)
+	}
+}
+
 type Node struct {
 	ID   string
 	Hash uint64
 }
 
 // ConsistentHashRing representa o hash ring que contém vários nós.
-// Implementa a interface interfaces.HashRing
+// Quando LoadFactor é maior que zero, aplica o consistent hashing with bounded loads
+// (Mirrokni et al., 2016): nenhum nó recebe mais que (1+LoadFactor) vezes a carga média.
+//
+// As réplicas virtuais ficam em um snapshot imutável publicado via ponteiro atômico:
+// lookups nunca adquirem locks, e alterações de membros constroem um novo anel e o
+// substituem atomicamente.
+// Implementa as interfaces interfaces.LoadAwareHashRing, interfaces.WeightedHashRing e interfaces.SnapshotHashRing
 type ConsistentHashRing struct {
-	Nodes         []Node
 	NumReplicas   int
 	HashAlgorithm string
+	HashSeed      uint64
+	LoadFactor    float64
 	hashFunc      func(string) uint64
+
+	mu    sync.Mutex
+	state atomic.Pointer[consistentState]
 }
 
-// Garantir que ConsistentHashRing implementa a interface HashRing
-var _ interfaces.HashRing = (*ConsistentHashRing)(nil)
+// consistentState é o snapshot imutável do anel. As réplicas virtuais estão
+// ordenadas por hash e os contadores de carga são compartilhados entre snapshots.
+// O peso configurado de cada nó é guardado para ser exportado no snapshot.
+type consistentState struct {
+	nodes   []Node
+	loads   map[string]*atomic.Int64
+	weights map[string]int
+}
+
+// Garantir que ConsistentHashRing implementa as interfaces LoadAwareHashRing, WeightedHashRing e SnapshotHashRing
+var (
+	_ interfaces.LoadAwareHashRing = (*ConsistentHashRing)(nil)
+	_ interfaces.WeightedHashRing  = (*ConsistentHashRing)(nil)
+	_ interfaces.SnapshotHashRing  = (*ConsistentHashRing)(nil)
+)
 
 // NewConsistentHashRing cria um novo anel de hash ring.
 func NewConsistentHashRing(numReplicas int) interfaces.HashRing {
 	ring := &ConsistentHashRing{
-		Nodes:       []Node{},
 		NumReplicas: numReplicas,
 	}
+	ring.state.Store(&consistentState{
+		nodes:   []Node{},
+		loads:   make(map[string]*atomic.Int64),
+		weights: make(map[string]int),
+	})
 
 	// Configurar algoritmo de hash baseado na variável de ambiente
 	ring.configureHashAlgorithm()
@@ -61,56 +186,331 @@ func (ring *ConsistentHashRing) GetHashAlgorithm() string {
 	return ring.HashAlgorithm
 }
 
+// VirtualNodes retorna as réplicas virtuais do snapshot atual, ordenadas por hash.
+// O slice retornado é compartilhado e não deve ser modificado.
+func (ring *ConsistentHashRing) VirtualNodes() []Node {
+	return ring.state.Load().nodes
+}
+
 // configureHashAlgorithm configura o algoritmo de hash baseado na variável HASHING_ALGORITHM
 func (ring *ConsistentHashRing) configureHashAlgorithm() {
+	ring.hashFunc, ring.HashAlgorithm, ring.HashSeed = resolveHashAlgorithm()
+}
+
+// resolveHashAlgorithm retorna a função de hash configurada nas variáveis HASHING_ALGORITHM
+// e HASHING_SEED junto com o nome do algoritmo e a seed. É compartilhada por todas as implementações de hash ring.
+// Uma seed rejeitada aqui só é ignorada pelos construtores diretos; NewHashRing falha antes.
+func resolveHashAlgorithm() (func(string) uint64, string, uint64) {
 	algorithm := HashAlgorithm(strings.ToUpper(os.Getenv("HASHING_ALGORITHM")))
+	if !slices.Contains(SupportedHashAlgorithms, algorithm) {
+		// Default para SHA512 se não especificado ou inválido
+		if algorithm != "" {
+			log.Printf("Unknown hash algorithm '%s', defaulting to SHA512", algorithm)
+		} else {
+			log.Printf("No HASHING_ALGORITHM specified, defaulting to SHA512")
+		}
+		algorithm = SHA512
+	} else {
+		log.Printf("Hash algorithm configured: %s", algorithm)
+	}
 
+	seed, err := resolveHashSeed()
+	if err != nil {
+		log.Printf("%v, ignoring seed", err)
+		seed = 0
+	}
+	hashFunc, err := NewHashFunc(algorithm, seed)
+	if err != nil {
+		log.Printf("%v, ignoring seed", err)
+		seed = 0
+		hashFunc, _ = NewHashFunc(algorithm, seed)
+	}
+	if seed != 0 {
+		log.Printf("Hash seed configured")
+	}
+	return hashFunc, string(algorithm), seed
+}
+
+// resolveHashSeed lê a seed opcional da variável HASHING_SEED (decimal ou hexadecimal com prefixo 0x).
+// O valor não é incluído no erro, já que a seed é secreta.
+func resolveHashSeed() (uint64, error) {
+	value := os.Getenv("HASHING_SEED")
+	if value == "" {
+		return 0, nil
+	}
+	seed, err := strconv.ParseUint(value, 0, 64)
+	if err != nil {
+		return 0, errors.New("invalid HASHING_SEED: expected an unsigned 64-bit decimal or 0x-prefixed hexadecimal value")
+	}
+	return seed, nil
+}
+
+// checkHashSeed valida HASHING_SEED para o algoritmo de HASHING_ALGORITHM. Ignorar uma
+// seed inválida desativaria silenciosamente a proteção que ela deveria dar.
+func checkHashSeed() error {
+	seed, err := resolveHashSeed()
+	if err != nil {
+		return err
+	}
+	algorithm := HashAlgorithm(strings.ToUpper(os.Getenv("HASHING_ALGORITHM")))
+	if !slices.Contains(SupportedHashAlgorithms, algorithm) {
+		algorithm = SHA512
+	}
+	_, err = NewHashFunc(algorithm, seed)
+	return err
+}
+
+// NewHashFunc retorna a função de hash do algoritmo informado com a seed aplicada.
+// Com seed zero, cada algoritmo produz os mesmos valores da sua versão sem seed.
+// Algoritmos com seed nativa (MURMUR3, XXHASH64, CRC32, CRC64, SIPHASH) a utilizam
+// diretamente; os demais recebem a seed como prefixo da chave. MURMUR3 e CRC32 têm
+// seed de 32 bits, e seeds maiores retornam erro em vez de serem truncadas.
+// Apenas SIPHASH é uma PRF com chave: com os demais, a seed muda a distribuição,
+// mas não impede que um cliente construa chaves que colidem.
+func NewHashFunc(algorithm HashAlgorithm, seed uint64) (func(string) uint64, error) {
+	if (algorithm == MURMUR3 || algorithm == CRC32) && seed > math.MaxUint32 {
+		return nil, fmt.Errorf("%s only supports 32-bit seeds, HASHING_SEED must not exceed 0xffffffff", algorithm)
+	}
 	switch algorithm {
 	case MD5:
-		ring.hashFunc = hashKeyMD5
-		log.Printf("Hash algorithm configured: MD5")
+		return withSeedPrefix(hashKeyMD5, seed), nil
 	case SHA1:
-		ring.hashFunc = hashKeySHA1
-		log.Printf("Hash algorithm configured: SHA1")
+		return withSeedPrefix(hashKeySHA1, seed), nil
 	case SHA256:
-		ring.hashFunc = hashKeySHA256
-		log.Printf("Hash algorithm configured: SHA256")
+		return withSeedPrefix(hashKeySHA256, seed), nil
 	case SHA512:
-		ring.hashFunc = hashKeySHA512
-		log.Printf("Hash algorithm configured: SHA512")
+		return withSeedPrefix(hashKeySHA512, seed), nil
+	case FNV1A:
+		return withSeedPrefix(hashKeyFNV1a, seed), nil
 	case MURMUR3:
-		ring.hashFunc = hashKeyMurmur3
-		log.Printf("Hash algorithm configured: MURMUR")
+		return func(s string) uint64 { return hashKeyMurmur3(s, uint32(seed)) }, nil
+	case XXHASH64:
+		return func(s string) uint64 { return hashKeyXXHash64(s, seed) }, nil
+	case CRC32:
+		return func(s string) uint64 { return hashKeyCRC32(s, uint32(seed)) }, nil
+	case CRC64:
+		return func(s string) uint64 { return hashKeyCRC64(s, seed) }, nil
+	case SIPHASH:
+		return func(s string) uint64 { return hashKeySipHash(s, seed) }, nil
 	default:
-		// Default para SHA512 se não especificado ou inválido
-		ring.hashFunc = hashKeySHA512
-		if algorithm != "" {
-			log.Printf("Unknown hash algorithm '%s', defaulting to SHA512", algorithm)
-		} else {
-			log.Printf("No HASHING_ALGORITHM specified, defaulting to SHA512")
-		}
-		algorithm = "SHA512"
+		return nil, fmt.Errorf("unknown hash algorithm '%s'", algorithm)
+	}
+}
+
+// withSeedPrefix aplica a seed como prefixo hexadecimal da chave para algoritmos sem seed nativa
+func withSeedPrefix(hashFunc func(string) uint64, seed uint64) func(string) uint64 {
+	if seed == 0 {
+		return hashFunc
+	}
+	prefix := fmt.Sprintf("%016x:", seed)
+	return func(s string) uint64 {
+		return hashFunc(prefix + s)
 	}
-	ring.HashAlgorithm = string(algorithm)
 }
 
 // AddNode adiciona um nó ao hash ring com múltiplas réplicas virtuais
-func (ring *ConsistentHashRing) AddNode(nodeID string) {
-	for i := 0; i < ring.NumReplicas; i++ {
+func (ring *ConsistentHashRing) AddNode(nodeID string) error {
+	return ring.AddWeightedNode(nodeID, 1)
+}
+
+// AddWeightedNode adiciona um nó com NumReplicas * weight réplicas virtuais,
+// de forma que a fração do anel ocupada seja proporcional à capacidade do nó.
+// Se o nó já existir, suas réplicas são substituídas pelas do novo peso.
+func (ring *ConsistentHashRing) AddWeightedNode(nodeID string, weight int) error {
+	if weight < 1 {
+		weight = 1
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load()
+	nodes := withoutNode(current.nodes, nodeID)
+	for i := 0; i < ring.NumReplicas*weight; i++ {
 		replicaID := nodeID + strconv.Itoa(i)
 		hash := ring.hashFunc(replicaID)
-		ring.Nodes = append(ring.Nodes, Node{ID: nodeID, Hash: hash})
+		nodes = append(nodes, Node{ID: nodeID, Hash: hash})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Hash < nodes[j].Hash
+	})
+
+	loads := copyLoads(current.loads, "")
+	if _, ok := loads[nodeID]; !ok {
+		loads[nodeID] = new(atomic.Int64)
+	}
+
+	weights := make(map[string]int, len(current.weights)+1)
+	for node, w := range current.weights {
+		weights[node] = w
+	}
+	weights[nodeID] = weight
+
+	ring.state.Store(&consistentState{nodes: nodes, loads: loads, weights: weights})
+	return nil
+}
+
+// RemoveNode remove todas as réplicas virtuais do nó. As chaves que pertenciam
+// a ele passam para o próximo nó no sentido horário do anel.
+func (ring *ConsistentHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := ring.state.Load()
+	weights := make(map[string]int, len(current.weights))
+	for node, w := range current.weights {
+		if node != nodeID {
+			weights[node] = w
+		}
 	}
-	sort.Slice(ring.Nodes, func(i, j int) bool {
-		return ring.Nodes[i].Hash < ring.Nodes[j].Hash
+	ring.state.Store(&consistentState{
+		nodes:   withoutNode(current.nodes, nodeID),
+		loads:   copyLoads(current.loads, nodeID),
+		weights: weights,
 	})
 }
 
+// ListNodes retorna os nós físicos presentes no anel, ordenados pelo ID
+func (ring *ConsistentHashRing) ListNodes() []string {
+	state := ring.state.Load()
+	nodes := make([]string, 0, len(state.loads))
+	for nodeID := range state.loads {
+		nodes = append(nodes, nodeID)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Snapshot exporta a topologia do anel com os hashes das réplicas virtuais de cada nó.
+func (ring *ConsistentHashRing) Snapshot() interfaces.RingSnapshot {
+	state := ring.state.Load()
+	hashes := groupHashes(state.nodes)
+
+	nodes := make([]interfaces.SnapshotNode, 0, len(state.loads))
+	for _, nodeID := range ring.ListNodes() {
+		nodes = append(nodes, interfaces.SnapshotNode{
+			ID:     nodeID,
+			Weight: state.weights[nodeID],
+			Hashes: hashes[nodeID],
+		})
+	}
+
+	return interfaces.RingSnapshot{
+		Type:            string(CONSISTENT),
+		HashAlgorithm:   ring.HashAlgorithm,
+		SeedFingerprint: SeedFingerprint(ring.HashSeed),
+		Replicas:        ring.NumReplicas,
+		LoadFactor:      ring.LoadFactor,
+		Nodes:           nodes,
+	}
+}
+
+// withoutNode retorna uma cópia das réplicas virtuais exceto as do nó informado
+func withoutNode(current []Node, nodeID string) []Node {
+	nodes := make([]Node, 0, len(current))
+	for _, node := range current {
+		if node.ID != nodeID {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// copyLoads copia o mapa de contadores de carga, omitindo o nó informado.
+// Os contadores são os mesmos ponteiros, preservando a carga entre snapshots.
+func copyLoads(current map[string]*atomic.Int64, skip string) map[string]*atomic.Int64 {
+	loads := make(map[string]*atomic.Int64, len(current)+1)
+	for nodeID, load := range current {
+		if nodeID != skip {
+			loads[nodeID] = load
+		}
+	}
+	return loads
+}
+
+// IncrementLoad registra o início de uma requisição em andamento no nó
+func (ring *ConsistentHashRing) IncrementLoad(nodeID string) {
+	if load, ok := ring.state.Load().loads[nodeID]; ok {
+		load.Add(1)
+	}
+}
+
+// DecrementLoad registra o fim de uma requisição em andamento no nó
+func (ring *ConsistentHashRing) DecrementLoad(nodeID string) {
+	load, ok := ring.state.Load().loads[nodeID]
+	if !ok {
+		return
+	}
+	for {
+		current := load.Load()
+		if current <= 0 || load.CompareAndSwap(current, current-1) {
+			return
+		}
+	}
+}
+
+// GetLoad retorna o número de requisições em andamento no nó
+func (ring *ConsistentHashRing) GetLoad(nodeID string) int64 {
+	if load, ok := ring.state.Load().loads[nodeID]; ok {
+		return load.Load()
+	}
+	return 0
+}
+
+// boundedNode percorre o anel no sentido horário a partir de idx até encontrar
+// um nó cuja carga esteja abaixo do limite ceil((1+LoadFactor) * média)
+func (ring *ConsistentHashRing) boundedNode(state *consistentState, idx int) string {
+	var totalLoad int64
+	for _, load := range state.loads {
+		totalLoad += load.Load()
+	}
+
+	// A média considera a requisição que está sendo roteada
+	average := float64(totalLoad+1) / float64(len(state.loads))
+	capacity := int64(math.Ceil(average * (1 + ring.LoadFactor)))
+
+	for i := 0; i < len(state.nodes); i++ {
+		node := state.nodes[(idx+i)%len(state.nodes)]
+		if state.loads[node.ID].Load() < capacity {
+			return node.ID
+		}
+	}
+	return state.nodes[idx].ID
+}
+
+// GetNodes retorna até n nós físicos distintos percorrendo o anel no sentido horário
+// a partir da posição da chave. O primeiro nó é o mesmo retornado por GetNode
+// quando o bounded loads está desabilitado.
+func (ring *ConsistentHashRing) GetNodes(key string, n int) []string {
+	state := ring.state.Load()
+	if n > len(state.loads) {
+		n = len(state.loads)
+	}
+	if n <= 0 || len(state.nodes) == 0 {
+		return []string{}
+	}
+
+	hash := ring.hashFunc(key)
+	idx := sort.Search(len(state.nodes), func(i int) bool {
+		return state.nodes[i].Hash >= hash
+	})
+
+	nodes := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for i := 0; i < len(state.nodes) && len(nodes) < n; i++ {
+		node := state.nodes[(idx+i)%len(state.nodes)]
+		if !seen[node.ID] {
+			seen[node.ID] = true
+			nodes = append(nodes, node.ID)
+		}
+	}
+	return nodes
+}
+
 // Implementações dos algoritmos de hash
 
 // hashKeyMD5 calcula hash MD5
 func hashKeyMD5(s string) uint64 {
-	s = strings.ToLower(s)
 	hasher := md5.New()
 	hasher.Write([]byte(s))
 	hashBytes := hasher.Sum(nil)
@@ -119,7 +519,6 @@ func hashKeyMD5(s string) uint64 {
 
 // hashKeySHA1 calcula hash SHA1
 func hashKeySHA1(s string) uint64 {
-	s = strings.ToLower(s)
 	hasher := sha1.New()
 	hasher.Write([]byte(s))
 	hashBytes := hasher.Sum(nil)
@@ -128,7 +527,6 @@ func hashKeySHA1(s string) uint64 {
 
 // hashKeySHA256 calcula hash SHA256
 func hashKeySHA256(s string) uint64 {
-	s = strings.ToLower(s)
 	hasher := sha256.New()
 	hasher.Write([]byte(s))
 	hashBytes := hasher.Sum(nil)
@@ -137,7 +535,6 @@ func hashKeySHA256(s string) uint64 {
 
 // hashKeySHA512 calcula hash SHA512 (função original)
 func hashKeySHA512(s string) uint64 {
-	s = strings.ToLower(s)
 	hasher := sha512.New()
 	hasher.Write([]byte(s))
 	hashBytes := hasher.Sum(nil)
@@ -145,28 +542,68 @@ func hashKeySHA512(s string) uint64 {
 }
 
 // hashKeyMurmur calcula hash Murmur3
-func hashKeyMurmur3(s string) uint64 {
-	s = strings.ToLower(s)
-	return murmur3.Sum64([]byte(s))
+func hashKeyMurmur3(s string, seed uint32) uint64 {
+	return murmur3.Sum64WithSeed([]byte(s), seed)
+}
+
+// hashKeyXXHash64 calcula hash xxHash64
+func hashKeyXXHash64(s string, seed uint64) uint64 {
+	if seed == 0 {
+		return xxhash.Sum64String(s)
+	}
+	hasher := xxhash.NewWithSeed(seed)
+	hasher.WriteString(s)
+	return hasher.Sum64()
 }
 
+// hashKeyFNV1a calcula hash FNV-1a de 64 bits
+func hashKeyFNV1a(s string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(s))
+	return hasher.Sum64()
+}
+
+// hashKeyCRC32 calcula hash CRC32 (IEEE), usando a seed como valor inicial
+func hashKeyCRC32(s string, seed uint32) uint64 {
+	return uint64(crc32.Update(seed, crc32.IEEETable, []byte(s)))
+}
+
+// hashKeyCRC64 calcula hash CRC64 (ECMA), usando a seed como valor inicial
+func hashKeyCRC64(s string, seed uint64) uint64 {
+	return crc64.Update(seed, crc64ECMATable, []byte(s))
+}
+
+// hashKeySipHash calcula hash SipHash-2-4 com a chave de 128 bits (seed, seed).
+// Com uma seed secreta, clientes não conseguem prever a posição das chaves no anel.
+func hashKeySipHash(s string, seed uint64) uint64 {
+	return siphash.Hash(seed, seed, []byte(s))
+}
+
+// crc64ECMATable é a tabela do polinômio ECMA usada pelo CRC64
+var crc64ECMATable = crc64.MakeTable(crc64.ECMA)
+
 // GetNode retorna o node onde o Tenant deverá estar alocado
 func (ring *ConsistentHashRing) GetNode(key string) string {
-	if len(ring.Nodes) == 0 {
+	state := ring.state.Load()
+	if len(state.nodes) == 0 {
 		return ""
 	}
 
 	hash := ring.hashFunc(key)
-	idx := sort.Search(len(ring.Nodes), func(i int) bool {
-		return ring.Nodes[i].Hash >= hash
+	idx := sort.Search(len(state.nodes), func(i int) bool {
+		return state.nodes[i].Hash >= hash
 	})
 
 	// Se o índice estiver fora dos limites, retorna ao primeiro nó
-	if idx == len(ring.Nodes) {
+	if idx == len(state.nodes) {
 		idx = 0
 	}
 
-	return ring.Nodes[idx].ID
+	if ring.LoadFactor > 0 {
+		return ring.boundedNode(state, idx)
+	}
+
+	return state.nodes[idx].ID
 }
 
 // Exemplos do artigo: https://fidelissauro.dev/sharding/
@@ -1,6 +1,8 @@
 package hashring
 
 import (
+	"app/pkg/interfaces"
+	"fmt"
 	"os"
 	"testing"
 )
@@ -18,8 +20,8 @@ func TestNewConsistentHashRing(t *testing.T) {
 		t.Errorf("Expected NumReplicas to be 3, got %d", concreteRing.NumReplicas)
 	}
 
-	if len(concreteRing.Nodes) != 0 {
-		t.Errorf("Expected empty nodes slice, got %d nodes", len(concreteRing.Nodes))
+	if len(concreteRing.VirtualNodes()) != 0 {
+		t.Errorf("Expected empty nodes slice, got %d nodes", len(concreteRing.VirtualNodes()))
 	}
 }
 
@@ -29,20 +31,20 @@ func TestAddNode(t *testing.T) {
 	ring.AddNode("shard01")
 
 	concreteRing := ring.(*ConsistentHashRing)
-	if len(concreteRing.Nodes) != 3 {
-		t.Errorf("Expected 3 nodes after adding one shard, got %d", len(concreteRing.Nodes))
+	if len(concreteRing.VirtualNodes()) != 3 {
+		t.Errorf("Expected 3 nodes after adding one shard, got %d", len(concreteRing.VirtualNodes()))
 	}
 
 	// Verificar se todos os nós têm o mesmo ID mas hashes diferentes
-	for i, node := range concreteRing.Nodes {
+	for i, node := range concreteRing.VirtualNodes() {
 		if node.ID != "shard01" {
 			t.Errorf("Expected node %d ID to be 'shard01', got '%s'", i, node.ID)
 		}
 	}
 
 	// Verificar se os nós estão ordenados por hash
-	for i := 1; i < len(concreteRing.Nodes); i++ {
-		if concreteRing.Nodes[i-1].Hash >= concreteRing.Nodes[i].Hash {
+	for i := 1; i < len(concreteRing.VirtualNodes()); i++ {
+		if concreteRing.VirtualNodes()[i-1].Hash >= concreteRing.VirtualNodes()[i].Hash {
 			t.Error("Nodes should be sorted by hash")
 		}
 	}
@@ -127,3 +129,97 @@ func TestDistribution(t *testing.T) {
 	t.Logf("Distribution: %v", distribution)
 	os.Unsetenv("HASHING_ALGORITHM")
 }
+
+func TestNewHashRing(t *testing.T) {
+	tests := []struct {
+		name        string
+		ringType    string
+		expectError bool
+		expected    string
+	}{
+		{name: "Default", ringType: "", expected: "*hashring.ConsistentHashRing"},
+		{name: "Consistent", ringType: "CONSISTENT", expected: "*hashring.ConsistentHashRing"},
+		{name: "Jump", ringType: "JUMP", expected: "*hashring.JumpHashRing"},
+		{name: "Jump lowercase", ringType: "jump", expected: "*hashring.JumpHashRing"},
+		{name: "Rendezvous", ringType: "RENDEZVOUS", expected: "*hashring.RendezvousHashRing"},
+		{name: "Maglev", ringType: "MAGLEV", expected: "*hashring.MaglevHashRing"},
+		{name: "Ketama", ringType: "KETAMA", expected: "*hashring.KetamaHashRing"},
+		{name: "Multi-probe", ringType: "MULTIPROBE", expected: "*hashring.MultiProbeHashRing"},
+		{name: "Anchor", ringType: "ANCHOR", expected: "*hashring.AnchorHashRing"},
+		{name: "Invalid", ringType: "INVALID", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewHashRing(interfaces.HashRingConfig{Type: tt.ringType, Replicas: 3})
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := fmt.Sprintf("%T", ring); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewHashRing_DefaultVirtualNodes(t *testing.T) {
+	ring, err := NewHashRing(interfaces.HashRingConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if replicas := ring.(*ConsistentHashRing).NumReplicas; replicas != DefaultVirtualNodes {
+		t.Errorf("Expected %d virtual nodes, got %d", DefaultVirtualNodes, replicas)
+	}
+}
+
+// TestAddNode_MovesOnlyOneNth garante que, com número fixo de réplicas virtuais,
+// adicionar o shard N move aproximadamente 1/N das chaves e somente para o novo shard
+func TestAddNode_MovesOnlyOneNth(t *testing.T) {
+	for _, numShards := range []int{3, 5, 10} {
+		t.Run(fmt.Sprintf("%d-to-%d", numShards, numShards+1), func(t *testing.T) {
+			ring := NewConsistentHashRing(DefaultVirtualNodes)
+			for i := 1; i <= numShards; i++ {
+				ring.AddNode(fmt.Sprintf("http://shard%02d:80", i))
+			}
+
+			numKeys := 50000
+			before := make([]string, numKeys)
+			for i := 0; i < numKeys; i++ {
+				before[i] = ring.GetNode(fmt.Sprintf("tenant-%d", i))
+			}
+
+			newShard := fmt.Sprintf("http://shard%02d:80", numShards+1)
+			ring.AddNode(newShard)
+
+			moved := 0
+			for i := 0; i < numKeys; i++ {
+				after := ring.GetNode(fmt.Sprintf("tenant-%d", i))
+				if after == before[i] {
+					continue
+				}
+				if after != newShard {
+					t.Fatalf("Key tenant-%d moved from %s to %s instead of the new shard", i, before[i], after)
+				}
+				moved++
+			}
+
+			expected := 1.0 / float64(numShards+1)
+			ratio := float64(moved) / float64(numKeys)
+			if ratio < expected*0.7 || ratio > expected*1.3 {
+				t.Errorf("Expected around %.1f%% of keys to move, got %.1f%%", expected*100, ratio*100)
+			}
+
+			t.Logf("Moved %.2f%% of keys (expected ~%.2f%%)", ratio*100, expected*100)
+		})
+	}
+}
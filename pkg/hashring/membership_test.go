@@ -0,0 +1,135 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// membershipRings retorna uma instância de cada implementação de hash ring
+func membershipRings(t *testing.T) map[string]interfaces.HashRing {
+	t.Helper()
+
+	maglev, err := NewMaglevHashRing(5003)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	return map[string]interfaces.HashRing{
+		"CONSISTENT": NewConsistentHashRing(DefaultVirtualNodes),
+		"JUMP":       NewJumpHashRing(),
+		"RENDEZVOUS": NewRendezvousHashRing(),
+		"MAGLEV":     maglev,
+		"KETAMA":     NewKetamaHashRing(),
+		"MULTIPROBE": NewMultiProbeHashRing(DefaultMultiProbeProbes),
+		"ANCHOR":     NewAnchorHashRing(64),
+	}
+}
+
+func TestHashRing_RemoveNode(t *testing.T) {
+	for name, ring := range membershipRings(t) {
+		t.Run(name, func(t *testing.T) {
+			ring.AddNode("shard01")
+			ring.AddNode("shard02")
+			ring.AddNode("shard03")
+
+			ring.RemoveNode("shard02")
+
+			if nodes := ring.ListNodes(); !reflect.DeepEqual(nodes, []string{"shard01", "shard03"}) {
+				t.Errorf("Expected [shard01 shard03], got %v", nodes)
+			}
+
+			for i := 0; i < 1000; i++ {
+				if node := ring.GetNode(fmt.Sprintf("tenant-%d", i)); node == "shard02" {
+					t.Fatalf("Key tenant-%d still mapped to removed shard02", i)
+				}
+			}
+
+			// Remover um nó inexistente não deve alterar o anel
+			ring.RemoveNode("unknown")
+			if nodes := ring.ListNodes(); len(nodes) != 2 {
+				t.Errorf("Expected 2 nodes, got %v", nodes)
+			}
+
+			ring.RemoveNode("shard01")
+			ring.RemoveNode("shard03")
+			if node := ring.GetNode("tenant"); node != "" {
+				t.Errorf("Expected empty string for empty ring, got '%s'", node)
+			}
+		})
+	}
+}
+
+func TestHashRing_ListNodes(t *testing.T) {
+	for name, ring := range membershipRings(t) {
+		t.Run(name, func(t *testing.T) {
+			if nodes := ring.ListNodes(); len(nodes) != 0 {
+				t.Errorf("Expected no nodes, got %v", nodes)
+			}
+
+			ring.AddNode("shard01")
+			ring.AddNode("shard02")
+			ring.AddNode("shard01")
+
+			if nodes := ring.ListNodes(); !reflect.DeepEqual(nodes, []string{"shard01", "shard02"}) {
+				t.Errorf("Expected [shard01 shard02], got %v", nodes)
+			}
+		})
+	}
+}
+
+func TestConsistentHashRing_RemoveNode_MovesOnlyRemovedKeys(t *testing.T) {
+	ring := NewConsistentHashRing(DefaultVirtualNodes)
+	for i := 1; i <= 5; i++ {
+		ring.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+
+	numKeys := 10000
+	before := make([]string, numKeys)
+	for i := 0; i < numKeys; i++ {
+		before[i] = ring.GetNode(fmt.Sprintf("tenant-%d", i))
+	}
+
+	ring.RemoveNode("shard03")
+
+	for i := 0; i < numKeys; i++ {
+		after := ring.GetNode(fmt.Sprintf("tenant-%d", i))
+		if before[i] != "shard03" && after != before[i] {
+			t.Fatalf("Key tenant-%d moved from %s to %s after removing shard03", i, before[i], after)
+		}
+	}
+}
+
+func TestConsistentHashRing_RemoveNode_ReleasesLoad(t *testing.T) {
+	ring := NewConsistentHashRing(10).(*ConsistentHashRing)
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+
+	ring.IncrementLoad("shard01")
+	ring.IncrementLoad("shard02")
+	ring.RemoveNode("shard01")
+
+	if load := ring.GetLoad("shard01"); load != 0 {
+		t.Errorf("Expected no load for removed shard01, got %d", load)
+	}
+	if load := ring.GetLoad("shard02"); load != 1 {
+		t.Errorf("Expected load 1 for shard02, got %d", load)
+	}
+
+	// Requisições em andamento no shard removido não afetam os demais
+	ring.DecrementLoad("shard01")
+	if load := ring.GetLoad("shard02"); load != 1 {
+		t.Errorf("Expected load 1 for shard02, got %d", load)
+	}
+}
+
+func TestConsistentHashRing_AddWeightedNode_ReplacesExisting(t *testing.T) {
+	ring := NewConsistentHashRing(10).(*ConsistentHashRing)
+	ring.AddWeightedNode("shard01", 1)
+	ring.AddWeightedNode("shard01", 2)
+
+	if len(ring.VirtualNodes()) != 20 {
+		t.Errorf("Expected 20 virtual nodes after reweighting, got %d", len(ring.VirtualNodes()))
+	}
+}
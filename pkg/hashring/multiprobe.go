@@ -0,0 +1,169 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMultiProbeProbes é o número padrão de probes por chave. Com 21 probes
+// a razão entre o shard mais carregado e a carga média fica em torno de 1.05.
+const DefaultMultiProbeProbes = 21
+
+// MultiProbeHashRing implementa o Multi-Probe Consistent Hashing (Appleton & O'Reilly, 2015).
+// Cada nó ocupa um único ponto no anel e cada chave é hasheada k vezes (probes); a chave
+// pertence ao nó cujo ponto está mais próximo, no sentido horário, de algum dos probes.
+// O equilíbrio é semelhante ao de réplicas virtuais, mas a memória é O(nós) e não há
+// número de réplicas para ajustar.
+// Implementa a interface interfaces.SnapshotHashRing
+type MultiProbeHashRing struct {
+	Probes        int
+	HashAlgorithm string
+	HashSeed      uint64
+	hashFunc      func(string) uint64
+
+	mu    sync.Mutex
+	nodes atomic.Pointer[[]Node]
+}
+
+// Garantir que MultiProbeHashRing implementa a interface SnapshotHashRing
+var _ interfaces.SnapshotHashRing = (*MultiProbeHashRing)(nil)
+
+// NewMultiProbeHashRing cria um novo hash ring multi-probe com o número de probes informado.
+// Quando probes é zero ou negativo, utiliza DefaultMultiProbeProbes.
+func NewMultiProbeHashRing(probes int) interfaces.HashRing {
+	if probes <= 0 {
+		probes = DefaultMultiProbeProbes
+	}
+
+	ring := &MultiProbeHashRing{
+		Probes: probes,
+	}
+	ring.nodes.Store(&[]Node{})
+	ring.hashFunc, ring.HashAlgorithm, ring.HashSeed = resolveHashAlgorithm()
+	return ring
+}
+
+func (ring *MultiProbeHashRing) GetHashAlgorithm() string {
+	return ring.HashAlgorithm
+}
+
+// AddNode adiciona o ponto do nó ao anel
+func (ring *MultiProbeHashRing) AddNode(nodeID string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := *ring.nodes.Load()
+	for _, node := range current {
+		if node.ID == nodeID {
+			return nil
+		}
+	}
+
+	nodes := make([]Node, len(current), len(current)+1)
+	copy(nodes, current)
+	nodes = append(nodes, Node{ID: nodeID, Hash: ring.hashFunc(nodeID)})
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Hash == nodes[j].Hash {
+			return nodes[i].ID < nodes[j].ID
+		}
+		return nodes[i].Hash < nodes[j].Hash
+	})
+	ring.nodes.Store(&nodes)
+	return nil
+}
+
+// RemoveNode remove o ponto do nó. Apenas as chaves que pertenciam a ele são redistribuídas.
+func (ring *MultiProbeHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	nodes := withoutNode(*ring.nodes.Load(), nodeID)
+	ring.nodes.Store(&nodes)
+}
+
+// ListNodes retorna os nós ordenados pelo ID
+func (ring *MultiProbeHashRing) ListNodes() []string {
+	current := *ring.nodes.Load()
+	nodes := make([]string, len(current))
+	for i, node := range current {
+		nodes[i] = node.ID
+	}
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Snapshot exporta os nós com o ponto de cada um no anel.
+func (ring *MultiProbeHashRing) Snapshot() interfaces.RingSnapshot {
+	hashes := groupHashes(*ring.nodes.Load())
+	nodes := unweightedSnapshotNodes(ring.ListNodes())
+	for i := range nodes {
+		nodes[i].Hashes = hashes[nodes[i].ID]
+	}
+
+	return interfaces.RingSnapshot{
+		Type:            string(MULTIPROBE),
+		HashAlgorithm:   ring.HashAlgorithm,
+		SeedFingerprint: SeedFingerprint(ring.HashSeed),
+		Probes:          ring.Probes,
+		Nodes:           nodes,
+	}
+}
+
+// GetNode retorna o nó mais próximo de algum dos probes da chave
+func (ring *MultiProbeHashRing) GetNode(key string) string {
+	nodes := *ring.nodes.Load()
+	if len(nodes) == 0 {
+		return ""
+	}
+	return nodes[ring.closest(nodes, key, nil)].ID
+}
+
+// GetNodes retorna até n nós distintos em ordem de preferência. Cada posição é o nó
+// que assumiria a chave se os anteriores fossem removidos do anel.
+func (ring *MultiProbeHashRing) GetNodes(key string, n int) []string {
+	nodes := *ring.nodes.Load()
+	if n > len(nodes) {
+		n = len(nodes)
+	}
+	if n <= 0 {
+		return []string{}
+	}
+
+	result := make([]string, 0, n)
+	excluded := make(map[string]bool, n)
+	for len(result) < n {
+		node := nodes[ring.closest(nodes, key, excluded)].ID
+		excluded[node] = true
+		result = append(result, node)
+	}
+	return result
+}
+
+// closest retorna o índice do nó, fora de excluded, com a menor distância horária até
+// algum dos probes. Os probes usam double hashing (h1 + i*h2), exigindo apenas dois
+// hashes por chave. Deve haver ao menos um nó fora de excluded.
+func (ring *MultiProbeHashRing) closest(nodes []Node, key string, excluded map[string]bool) int {
+	h1 := ring.hashFunc(key)
+	h2 := ring.hashFunc(key + "-probe")
+
+	best := 0
+	var minDistance uint64
+	for i := 0; i < ring.Probes; i++ {
+		probe := h1 + uint64(i)*h2
+		idx := sort.Search(len(nodes), func(j int) bool {
+			return nodes[j].Hash >= probe
+		}) % len(nodes)
+		for excluded[nodes[idx].ID] {
+			idx = (idx + 1) % len(nodes)
+		}
+
+		// A subtração em uint64 considera a volta do anel
+		if distance := nodes[idx].Hash - probe; i == 0 || distance < minDistance {
+			minDistance = distance
+			best = idx
+		}
+	}
+	return best
+}
@@ -0,0 +1,77 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMultiProbeHashRing_DefaultProbes(t *testing.T) {
+	ring := NewMultiProbeHashRing(0).(*MultiProbeHashRing)
+	if ring.Probes != DefaultMultiProbeProbes {
+		t.Errorf("Expected %d probes, got %d", DefaultMultiProbeProbes, ring.Probes)
+	}
+}
+
+func TestMultiProbeHashRing_OnePointPerNode(t *testing.T) {
+	ring := NewMultiProbeHashRing(DefaultMultiProbeProbes).(*MultiProbeHashRing)
+	for i := 1; i <= 100; i++ {
+		ring.AddNode(fmt.Sprintf("shard%03d", i))
+	}
+	ring.AddNode("shard001")
+
+	if points := len(*ring.nodes.Load()); points != 100 {
+		t.Errorf("Expected 100 points, got %d", points)
+	}
+}
+
+func TestMultiProbeHashRing_Distribution(t *testing.T) {
+	ring := NewMultiProbeHashRing(DefaultMultiProbeProbes)
+	numShards := 10
+	for i := 1; i <= numShards; i++ {
+		ring.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+
+	numKeys := 100000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		distribution[ring.GetNode(fmt.Sprintf("user-%d", i))]++
+	}
+
+	// Com 21 probes a razão pico/média esperada é ~1.05; a tolerância cobre a variância das chaves
+	expected := float64(numKeys) / float64(numShards)
+	for shard, count := range distribution {
+		if float64(count) > expected*1.25 || float64(count) < expected*0.75 {
+			t.Errorf("Shard %s has %d keys, expected around %.0f", shard, count, expected)
+		}
+	}
+
+	t.Logf("Distribution: %v", distribution)
+}
+
+func TestMultiProbeHashRing_MoreProbesImproveBalance(t *testing.T) {
+	peakToMean := func(probes int) float64 {
+		ring := NewMultiProbeHashRing(probes)
+		numShards := 20
+		for i := 1; i <= numShards; i++ {
+			ring.AddNode(fmt.Sprintf("shard%02d", i))
+		}
+
+		numKeys := 50000
+		distribution := make(map[string]int)
+		peak := 0
+		for i := 0; i < numKeys; i++ {
+			node := ring.GetNode(fmt.Sprintf("key-%d", i))
+			distribution[node]++
+			if distribution[node] > peak {
+				peak = distribution[node]
+			}
+		}
+		return float64(peak) / (float64(numKeys) / float64(numShards))
+	}
+
+	single, multi := peakToMean(1), peakToMean(DefaultMultiProbeProbes)
+	if multi >= single {
+		t.Errorf("Expected %d probes to improve balance over 1 probe, got %.2f vs %.2f", DefaultMultiProbeProbes, multi, single)
+	}
+	t.Logf("Peak-to-mean ratio: 1 probe=%.2f, %d probes=%.2f", single, DefaultMultiProbeProbes, multi)
+}
@@ -0,0 +1,73 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashRing_GetNodes(t *testing.T) {
+	for name, ring := range membershipRings(t) {
+		t.Run(name, func(t *testing.T) {
+			if nodes := ring.GetNodes("tenant", 2); len(nodes) != 0 {
+				t.Errorf("Expected no nodes for empty ring, got %v", nodes)
+			}
+
+			for i := 1; i <= 5; i++ {
+				ring.AddNode(fmt.Sprintf("shard%02d", i))
+			}
+
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("tenant-%d", i)
+				nodes := ring.GetNodes(key, 3)
+
+				if len(nodes) != 3 {
+					t.Fatalf("Expected 3 nodes, got %v", nodes)
+				}
+
+				if nodes[0] != ring.GetNode(key) {
+					t.Fatalf("Expected first node to be %s, got %s", ring.GetNode(key), nodes[0])
+				}
+
+				seen := make(map[string]bool)
+				for _, node := range nodes {
+					if seen[node] {
+						t.Fatalf("Node %s appears more than once in %v", node, nodes)
+					}
+					seen[node] = true
+				}
+			}
+
+			// n maior que o número de nós retorna todos os nós físicos
+			if nodes := ring.GetNodes("tenant", 10); len(nodes) != 5 {
+				t.Errorf("Expected 5 nodes, got %v", nodes)
+			}
+
+			if nodes := ring.GetNodes("tenant", 0); len(nodes) != 0 {
+				t.Errorf("Expected no nodes for n=0, got %v", nodes)
+			}
+		})
+	}
+}
+
+func TestHashRing_GetNodes_FailoverOrder(t *testing.T) {
+	// Ao remover o dono da chave, o novo dono deve ser o segundo da lista de preferência
+	for name, ring := range membershipRings(t) {
+		if name == "JUMP" || name == "MAGLEV" {
+			// Jump renumera buckets e Maglev reconstrói a tabela na remoção
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			for i := 1; i <= 5; i++ {
+				ring.AddNode(fmt.Sprintf("shard%02d", i))
+			}
+
+			key := "tenant-failover"
+			nodes := ring.GetNodes(key, 2)
+			ring.RemoveNode(nodes[0])
+
+			if owner := ring.GetNode(key); owner != nodes[1] {
+				t.Errorf("Expected %s to take over the key, got %s", nodes[1], owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// RendezvousHashRing implementa o Rendezvous Hashing (Highest Random Weight).
+// Para cada chave, todos os nós recebem um score calculado pela função de hash
+// configurada sobre o par nó/chave e o nó com maior score é o escolhido.
+// Ao remover um nó, apenas as chaves que pertenciam a ele são redistribuídas.
+// Implementa a interface interfaces.SnapshotHashRing
+type RendezvousHashRing struct {
+	HashAlgorithm string
+	HashSeed      uint64
+	hashFunc      func(string) uint64
+
+	mu    sync.Mutex
+	nodes atomic.Pointer[[]string]
+}
+
+// Garantir que RendezvousHashRing implementa a interface SnapshotHashRing
+var _ interfaces.SnapshotHashRing = (*RendezvousHashRing)(nil)
+
+// NewRendezvousHashRing cria um novo hash ring baseado em Rendezvous Hashing.
+func NewRendezvousHashRing() interfaces.HashRing {
+	ring := &RendezvousHashRing{}
+	ring.nodes.Store(&[]string{})
+	ring.hashFunc, ring.HashAlgorithm, ring.HashSeed = resolveHashAlgorithm()
+	return ring
+}
+
+func (ring *RendezvousHashRing) GetHashAlgorithm() string {
+	return ring.HashAlgorithm
+}
+
+// AddNode adiciona um nó ao conjunto de candidatos
+func (ring *RendezvousHashRing) AddNode(nodeID string) error {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := *ring.nodes.Load()
+	for _, node := range current {
+		if node == nodeID {
+			return nil
+		}
+	}
+
+	nodes := make([]string, len(current), len(current)+1)
+	copy(nodes, current)
+	nodes = append(nodes, nodeID)
+	ring.nodes.Store(&nodes)
+	return nil
+}
+
+// RemoveNode remove o nó do conjunto de candidatos. Apenas as chaves
+// que pertenciam a ele são redistribuídas.
+func (ring *RendezvousHashRing) RemoveNode(nodeID string) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	current := *ring.nodes.Load()
+	nodes := make([]string, 0, len(current))
+	for _, node := range current {
+		if node != nodeID {
+			nodes = append(nodes, node)
+		}
+	}
+	ring.nodes.Store(&nodes)
+}
+
+// ListNodes retorna os nós candidatos ordenados pelo ID
+func (ring *RendezvousHashRing) ListNodes() []string {
+	current := *ring.nodes.Load()
+	nodes := make([]string, len(current))
+	copy(nodes, current)
+	sort.Strings(nodes)
+	return nodes
+}
+
+// Snapshot exporta os nós candidatos. O Rendezvous não possui posições no anel,
+// portanto o snapshot não contém hashes.
+func (ring *RendezvousHashRing) Snapshot() interfaces.RingSnapshot {
+	return interfaces.RingSnapshot{
+		Type:            string(RENDEZVOUS),
+		HashAlgorithm:   ring.HashAlgorithm,
+		SeedFingerprint: SeedFingerprint(ring.HashSeed),
+		Nodes:           unweightedSnapshotNodes(ring.ListNodes()),
+	}
+}
+
+// GetNode retorna o node com o maior score para a chave
+func (ring *RendezvousHashRing) GetNode(key string) string {
+	var winner string
+	var best uint64
+	for _, node := range *ring.nodes.Load() {
+		score := ring.score(node, key)
+		if winner == "" || score > best || (score == best && node < winner) {
+			winner = node
+			best = score
+		}
+	}
+	return winner
+}
+
+// GetRankedNodes retorna todos os nós ordenados do maior para o menor score.
+// O primeiro elemento é sempre o mesmo retornado por GetNode, e os seguintes
+// formam a ordem de preferência para failover da chave.
+func (ring *RendezvousHashRing) GetRankedNodes(key string) []string {
+	type candidate struct {
+		node  string
+		score uint64
+	}
+
+	current := *ring.nodes.Load()
+	candidates := make([]candidate, len(current))
+	for i, node := range current {
+		candidates[i] = candidate{node: node, score: ring.score(node, key)}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score == candidates[j].score {
+			return candidates[i].node < candidates[j].node
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	ranked := make([]string, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = c.node
+	}
+	return ranked
+}
+
+// GetNodes retorna os n nós com maior score para a chave
+func (ring *RendezvousHashRing) GetNodes(key string, n int) []string {
+	ranked := ring.GetRankedNodes(key)
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	if n <= 0 {
+		return []string{}
+	}
+	return ranked[:n]
+}
+
+// score calcula o peso do par nó/chave reutilizando a função de hash configurada
+func (ring *RendezvousHashRing) score(nodeID, key string) uint64 {
+	return ring.hashFunc(nodeID + "-" + key)
+}
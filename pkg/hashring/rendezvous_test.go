@@ -0,0 +1,105 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRendezvousHashRing_GetNode(t *testing.T) {
+	ring := NewRendezvousHashRing()
+
+	if node := ring.GetNode("test-key"); node != "" {
+		t.Errorf("Expected empty string for empty ring, got '%s'", node)
+	}
+
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+	ring.AddNode("shard03")
+
+	key := "user123"
+	first := ring.GetNode(key)
+	if first == "" {
+		t.Fatal("Expected a valid shard")
+	}
+
+	for i := 0; i < 10; i++ {
+		if result := ring.GetNode(key); result != first {
+			t.Errorf("GetNode should be deterministic. First result: %s, iteration %d result: %s", first, i, result)
+		}
+	}
+}
+
+func TestRendezvousHashRing_GetRankedNodes(t *testing.T) {
+	ring := NewRendezvousHashRing().(*RendezvousHashRing)
+	shards := []string{"shard01", "shard02", "shard03", "shard04"}
+	for _, shard := range shards {
+		ring.AddNode(shard)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("tenant-%d", i)
+		ranked := ring.GetRankedNodes(key)
+
+		if len(ranked) != len(shards) {
+			t.Fatalf("Expected %d ranked nodes, got %d", len(shards), len(ranked))
+		}
+
+		if ranked[0] != ring.GetNode(key) {
+			t.Errorf("Expected first ranked node to be '%s', got '%s'", ring.GetNode(key), ranked[0])
+		}
+
+		seen := make(map[string]bool)
+		for _, node := range ranked {
+			if seen[node] {
+				t.Errorf("Node %s appears more than once in ranking %v", node, ranked)
+			}
+			seen[node] = true
+		}
+	}
+}
+
+func TestRendezvousHashRing_MinimalMovementOnRemoval(t *testing.T) {
+	before := NewRendezvousHashRing()
+	after := NewRendezvousHashRing()
+	for _, shard := range []string{"shard01", "shard02", "shard03", "shard04"} {
+		before.AddNode(shard)
+		if shard != "shard03" {
+			after.AddNode(shard)
+		}
+	}
+
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("tenant-%d", i)
+		previous := before.GetNode(key)
+		current := after.GetNode(key)
+
+		// Somente chaves do shard removido podem mudar de dono
+		if previous != "shard03" && previous != current {
+			t.Fatalf("Key %s moved from %s to %s after removing shard03", key, previous, current)
+		}
+	}
+}
+
+func TestRendezvousHashRing_Distribution(t *testing.T) {
+	ring := NewRendezvousHashRing()
+	shards := []string{"shard01", "shard02", "shard03"}
+	for _, shard := range shards {
+		ring.AddNode(shard)
+	}
+
+	numKeys := 30000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		distribution[ring.GetNode(fmt.Sprintf("user-%d", i))]++
+	}
+
+	expected := float64(numKeys) / float64(len(shards))
+	for _, shard := range shards {
+		count := float64(distribution[shard])
+		if count < expected*0.9 || count > expected*1.1 {
+			t.Errorf("Shard %s has %d keys, expected around %.0f", shard, distribution[shard], expected)
+		}
+	}
+
+	t.Logf("Distribution: %v", distribution)
+}
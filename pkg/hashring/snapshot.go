@@ -0,0 +1,336 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+)
+
+// snapshotMagic identifica o formato binário do snapshot, seguido da versão do formato
+const (
+	snapshotMagic   = "MSRS"
+	snapshotVersion = 2
+)
+
+// SeedFingerprint retorna a impressão digital exportada nos snapshots no lugar da seed:
+// os primeiros 8 bytes do SHA-256 da seed, em hexadecimal. Sem seed, retorna vazio.
+// Permite verificar que duas réplicas usam a mesma seed sem expô-la.
+func SeedFingerprint(seed uint64) string {
+	if seed == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(binary.BigEndian.AppendUint64(nil, seed))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NewHashRingFromSnapshot cria um hash ring com a topologia do snapshot, ignorando
+// HASHING_ALGORITHM. A seed, que não é exportada, vem de HASHING_SEED e precisa
+// corresponder à impressão digital do snapshot. Quando o snapshot contém hashes,
+// o anel reconstruído é comparado com eles e qualquer divergência é retornada como erro.
+func NewHashRingFromSnapshot(snapshot interfaces.RingSnapshot) (interfaces.HashRing, error) {
+	ring, err := newHashRing(interfaces.HashRingConfig{
+		Type:       snapshot.Type,
+		Replicas:   snapshot.Replicas,
+		TableSize:  snapshot.TableSize,
+		LoadFactor: snapshot.LoadFactor,
+		Probes:     snapshot.Probes,
+		Capacity:   snapshot.Capacity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var seed uint64
+	if _, ok := ring.(*KetamaHashRing); !ok {
+		if seed, err = resolveHashSeed(); err != nil {
+			return nil, err
+		}
+	}
+	if SeedFingerprint(seed) != snapshot.SeedFingerprint {
+		return nil, fmt.Errorf("HASHING_SEED does not match the snapshot seed fingerprint '%s'", snapshot.SeedFingerprint)
+	}
+	if err := useHashFunc(ring, HashAlgorithm(snapshot.HashAlgorithm), seed); err != nil {
+		return nil, err
+	}
+
+	// Buckets do Jump dependem da ordem de inserção
+	nodes := slices.Clone(snapshot.Nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Bucket < nodes[j].Bucket
+	})
+
+	if anchor, ok := ring.(*AnchorHashRing); ok {
+		if err := anchor.restore(nodes, snapshot.RemovedBuckets); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, node := range nodes {
+			if weighted, ok := ring.(interfaces.WeightedHashRing); ok {
+				err = weighted.AddWeightedNode(node.ID, node.Weight)
+			} else {
+				err = ring.AddNode(node.ID)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := verifySnapshot(ring.(interfaces.SnapshotHashRing).Snapshot(), snapshot); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// verifySnapshot compara a topologia reconstruída com a do snapshot de origem
+func verifySnapshot(restored, expected interfaces.RingSnapshot) error {
+	if len(restored.Nodes) != len(expected.Nodes) {
+		return fmt.Errorf("snapshot has %d nodes but restored ring has %d", len(expected.Nodes), len(restored.Nodes))
+	}
+
+	byID := make(map[string]interfaces.SnapshotNode, len(restored.Nodes))
+	for _, node := range restored.Nodes {
+		byID[node.ID] = node
+	}
+	for _, node := range expected.Nodes {
+		got, ok := byID[node.ID]
+		if !ok {
+			return fmt.Errorf("node %s from snapshot is missing in restored ring", node.ID)
+		}
+		if node.Bucket != got.Bucket {
+			return fmt.Errorf("node %s restored in bucket %d, snapshot has bucket %d", node.ID, got.Bucket, node.Bucket)
+		}
+		if len(node.Hashes) > 0 && !slices.Equal(node.Hashes, got.Hashes) {
+			return fmt.Errorf("hashes of node %s do not match the snapshot", node.ID)
+		}
+	}
+	return nil
+}
+
+// useHashFunc substitui a função de hash de um hash ring recém-criado, ainda sem nós
+func useHashFunc(ring interfaces.HashRing, algorithm HashAlgorithm, seed uint64) error {
+	if _, ok := ring.(*KetamaHashRing); ok {
+		if algorithm != MD5 || seed != 0 {
+			return fmt.Errorf("KETAMA hash ring only supports MD5 without seed, got %s", algorithm)
+		}
+		return nil
+	}
+
+	hashFunc, err := NewHashFunc(algorithm, seed)
+	if err != nil {
+		return err
+	}
+
+	switch r := ring.(type) {
+	case *ConsistentHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	case *JumpHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	case *RendezvousHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	case *MaglevHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	case *MultiProbeHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	case *AnchorHashRing:
+		r.hashFunc, r.HashAlgorithm, r.HashSeed = hashFunc, string(algorithm), seed
+	default:
+		return fmt.Errorf("hash ring %T does not support snapshots", ring)
+	}
+	return nil
+}
+
+// groupHashes agrupa os hashes das posições por nó. As posições estão ordenadas
+// por hash, portanto os hashes de cada nó ficam em ordem crescente.
+func groupHashes(points []Node) map[string][]uint64 {
+	hashes := make(map[string][]uint64)
+	for _, point := range points {
+		hashes[point.ID] = append(hashes[point.ID], point.Hash)
+	}
+	return hashes
+}
+
+// unweightedSnapshotNodes cria os nós do snapshot com peso 1
+func unweightedSnapshotNodes(nodeIDs []string) []interfaces.SnapshotNode {
+	nodes := make([]interfaces.SnapshotNode, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		nodes[i] = interfaces.SnapshotNode{ID: nodeID, Weight: 1}
+	}
+	return nodes
+}
+
+// MarshalSnapshotJSON serializa o snapshot em JSON indentado, adequado para versionar e revisar
+func MarshalSnapshotJSON(snapshot interfaces.RingSnapshot) ([]byte, error) {
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// MarshalSnapshotBinary serializa o snapshot no formato binário compacto: o cabeçalho
+// "MSRS" com a versão, seguido dos campos em varints, strings prefixadas pelo tamanho
+// e hashes de 8 bytes em little-endian
+func MarshalSnapshotBinary(snapshot interfaces.RingSnapshot) []byte {
+	buf := []byte(snapshotMagic)
+	buf = append(buf, snapshotVersion)
+	buf = binary.AppendUvarint(buf, snapshot.Generation)
+	buf = appendString(buf, snapshot.Type)
+	buf = appendString(buf, snapshot.HashAlgorithm)
+	buf = appendString(buf, snapshot.SeedFingerprint)
+	buf = binary.AppendVarint(buf, int64(snapshot.Replicas))
+	buf = binary.AppendVarint(buf, int64(snapshot.TableSize))
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(snapshot.LoadFactor))
+	buf = binary.AppendVarint(buf, int64(snapshot.Probes))
+	buf = binary.AppendVarint(buf, int64(snapshot.Capacity))
+
+	buf = binary.AppendUvarint(buf, uint64(len(snapshot.Nodes)))
+	for _, node := range snapshot.Nodes {
+		buf = appendString(buf, node.ID)
+		buf = binary.AppendVarint(buf, int64(node.Weight))
+		buf = binary.AppendVarint(buf, int64(node.Bucket))
+		buf = binary.AppendUvarint(buf, uint64(len(node.Hashes)))
+		for _, hash := range node.Hashes {
+			buf = binary.LittleEndian.AppendUint64(buf, hash)
+		}
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(snapshot.RemovedBuckets)))
+	for _, b := range snapshot.RemovedBuckets {
+		buf = binary.AppendVarint(buf, int64(b))
+	}
+	return buf
+}
+
+// UnmarshalSnapshot decodifica um snapshot em JSON ou no formato binário, detectado pelo cabeçalho
+func UnmarshalSnapshot(data []byte) (interfaces.RingSnapshot, error) {
+	if bytes.HasPrefix(data, []byte(snapshotMagic)) {
+		return unmarshalSnapshotBinary(data)
+	}
+
+	var snapshot interfaces.RingSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return interfaces.RingSnapshot{}, fmt.Errorf("invalid ring snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// unmarshalSnapshotBinary decodifica o formato gerado por MarshalSnapshotBinary
+func unmarshalSnapshotBinary(data []byte) (interfaces.RingSnapshot, error) {
+	r := &snapshotReader{r: bytes.NewReader(data[len(snapshotMagic):])}
+	var snapshot interfaces.RingSnapshot
+
+	if version := r.byte(); r.err == nil && version != snapshotVersion {
+		return snapshot, fmt.Errorf("unsupported ring snapshot version %d", version)
+	}
+	snapshot.Generation = r.uvarint()
+	snapshot.Type = r.string()
+	snapshot.HashAlgorithm = r.string()
+	snapshot.SeedFingerprint = r.string()
+	snapshot.Replicas = r.varint()
+	snapshot.TableSize = r.varint()
+	snapshot.LoadFactor = math.Float64frombits(r.uint64())
+	snapshot.Probes = r.varint()
+	snapshot.Capacity = r.varint()
+
+	snapshot.Nodes = make([]interfaces.SnapshotNode, r.length())
+	for i := range snapshot.Nodes {
+		node := &snapshot.Nodes[i]
+		node.ID = r.string()
+		node.Weight = r.varint()
+		node.Bucket = r.varint()
+		if count := r.length(); count > 0 {
+			node.Hashes = make([]uint64, count)
+			for j := range node.Hashes {
+				node.Hashes[j] = r.uint64()
+			}
+		}
+	}
+
+	if count := r.length(); count > 0 {
+		snapshot.RemovedBuckets = make([]int, count)
+		for i := range snapshot.RemovedBuckets {
+			snapshot.RemovedBuckets[i] = r.varint()
+		}
+	}
+
+	if r.err != nil {
+		return interfaces.RingSnapshot{}, fmt.Errorf("invalid binary ring snapshot: %w", r.err)
+	}
+	return snapshot, nil
+}
+
+// appendString adiciona uma string prefixada pelo seu tamanho
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// snapshotReader lê os campos do formato binário guardando o primeiro erro encontrado
+type snapshotReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (sr *snapshotReader) byte() byte {
+	if sr.err != nil {
+		return 0
+	}
+	b, err := sr.r.ReadByte()
+	sr.err = err
+	return b
+}
+
+func (sr *snapshotReader) uvarint() uint64 {
+	if sr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(sr.r)
+	sr.err = err
+	return v
+}
+
+func (sr *snapshotReader) varint() int {
+	if sr.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(sr.r)
+	sr.err = err
+	return int(v)
+}
+
+func (sr *snapshotReader) uint64() uint64 {
+	if sr.err != nil {
+		return 0
+	}
+	var buf [8]byte
+	_, sr.err = io.ReadFull(sr.r, buf[:])
+	return binary.LittleEndian.Uint64(buf[:])
+}
+
+// length lê o tamanho de uma lista ou string. Cada elemento ocupa ao menos um byte,
+// portanto tamanhos maiores que os dados restantes indicam um snapshot corrompido.
+func (sr *snapshotReader) length() int {
+	n := sr.uvarint()
+	if sr.err == nil && n > uint64(sr.r.Len()) {
+		sr.err = errors.New("length out of range")
+	}
+	if sr.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (sr *snapshotReader) string() string {
+	n := sr.length()
+	if sr.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	_, sr.err = io.ReadFull(sr.r, buf)
+	return string(buf)
+}
@@ -0,0 +1,175 @@
+package hashring
+
+import (
+	"app/pkg/interfaces"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	for name, ring := range membershipRings(t) {
+		t.Run(name, func(t *testing.T) {
+			for i := 1; i <= 8; i++ {
+				if weighted, ok := ring.(interfaces.WeightedHashRing); ok {
+					weighted.AddWeightedNode(fmt.Sprintf("http://shard%02d:80", i), i%3+1)
+				} else {
+					ring.AddNode(fmt.Sprintf("http://shard%02d:80", i))
+				}
+			}
+			ring.RemoveNode("http://shard03:80")
+			ring.RemoveNode("http://shard06:80")
+
+			snapshot := ring.(interfaces.SnapshotHashRing).Snapshot()
+			if snapshot.Type != name {
+				t.Errorf("Expected type %s, got %s", name, snapshot.Type)
+			}
+
+			jsonData, err := MarshalSnapshotJSON(snapshot)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for format, data := range map[string][]byte{"json": jsonData, "binary": MarshalSnapshotBinary(snapshot)} {
+				decoded, err := UnmarshalSnapshot(data)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", format, err)
+				}
+				if !reflect.DeepEqual(decoded, snapshot) {
+					t.Fatalf("%s: decoded snapshot differs from original", format)
+				}
+
+				restored, err := NewHashRingFromSnapshot(decoded)
+				if err != nil {
+					t.Fatalf("%s: unexpected error: %v", format, err)
+				}
+				for i := 0; i < 2000; i++ {
+					key := fmt.Sprintf("user-%d", i)
+					if restored.GetNode(key) != ring.GetNode(key) {
+						t.Fatalf("%s: key %s mapped to %s, original ring mapped to %s", format, key, restored.GetNode(key), ring.GetNode(key))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshot_UsesSnapshotHashAlgorithm(t *testing.T) {
+	t.Setenv("HASHING_ALGORITHM", "SHA256")
+	t.Setenv("HASHING_SEED", "7")
+	ring := NewConsistentHashRing(10)
+	ring.AddNode("shard01")
+	ring.AddNode("shard02")
+	snapshot := ring.(interfaces.SnapshotHashRing).Snapshot()
+	if snapshot.SeedFingerprint != SeedFingerprint(7) {
+		t.Errorf("Expected seed fingerprint '%s', got '%s'", SeedFingerprint(7), snapshot.SeedFingerprint)
+	}
+
+	// Uma réplica com outro algoritmo deve seguir o snapshot, com a seed de HASHING_SEED
+	t.Setenv("HASHING_ALGORITHM", "MD5")
+	restored, err := NewHashRingFromSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if restored.GetHashAlgorithm() != "SHA256" || restored.(*ConsistentHashRing).HashSeed != 7 {
+		t.Errorf("Expected SHA256 with seed 7, got %s with seed %d", restored.GetHashAlgorithm(), restored.(*ConsistentHashRing).HashSeed)
+	}
+
+	// A seed não vem do snapshot: sem HASHING_SEED, a impressão digital não corresponde
+	for _, seed := range []string{"", "8"} {
+		t.Setenv("HASHING_SEED", seed)
+		if _, err := NewHashRingFromSnapshot(snapshot); err == nil {
+			t.Errorf("Expected error for HASHING_SEED '%s' not matching the snapshot", seed)
+		}
+	}
+}
+
+func TestSeedFingerprint(t *testing.T) {
+	if SeedFingerprint(0) != "" {
+		t.Errorf("Expected empty fingerprint without seed, got '%s'", SeedFingerprint(0))
+	}
+	if len(SeedFingerprint(7)) != 16 || SeedFingerprint(7) == SeedFingerprint(8) {
+		t.Errorf("Expected distinct 16-character fingerprints, got '%s' and '%s'", SeedFingerprint(7), SeedFingerprint(8))
+	}
+}
+
+func TestSnapshot_DetectsMismatch(t *testing.T) {
+	ring := NewConsistentHashRing(10)
+	ring.AddNode("shard01")
+	snapshot := ring.(interfaces.SnapshotHashRing).Snapshot()
+	snapshot.Nodes[0].Hashes[0]++
+
+	if _, err := NewHashRingFromSnapshot(snapshot); err == nil {
+		t.Error("Expected error for snapshot with tampered hashes")
+	}
+}
+
+func TestSnapshot_ConsistentKeepsConfiguredWeight(t *testing.T) {
+	// O peso não pode ser derivado do número de réplicas virtuais quando NumReplicas é zero
+	ring := NewConsistentHashRing(0)
+	ring.(interfaces.WeightedHashRing).AddWeightedNode("shard01", 3)
+	ring.(interfaces.WeightedHashRing).AddWeightedNode("shard02", 2)
+	ring.RemoveNode("shard02")
+
+	snapshot := ring.(interfaces.SnapshotHashRing).Snapshot()
+	if len(snapshot.Nodes) != 1 || snapshot.Nodes[0].Weight != 3 {
+		t.Errorf("Expected only shard01 with weight 3, got %+v", snapshot.Nodes)
+	}
+}
+
+func TestSnapshot_WithoutHashes(t *testing.T) {
+	// Snapshots escritos à mão podem omitir os hashes
+	data := []byte(`{"type": "CONSISTENT", "hash_algorithm": "SHA512", "replicas": 10,
+		"nodes": [{"id": "shard01", "weight": 1}, {"id": "shard02", "weight": 2}]}`)
+
+	snapshot, err := UnmarshalSnapshot(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ring, err := NewHashRingFromSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(ring.(*ConsistentHashRing).VirtualNodes()) != 30 {
+		t.Errorf("Expected 30 virtual nodes, got %d", len(ring.(*ConsistentHashRing).VirtualNodes()))
+	}
+}
+
+func TestUnmarshalSnapshot_Invalid(t *testing.T) {
+	snapshot := interfaces.RingSnapshot{Type: "JUMP", HashAlgorithm: "SHA512", Nodes: []interfaces.SnapshotNode{{ID: "shard01", Weight: 1}}}
+	data := MarshalSnapshotBinary(snapshot)
+
+	for name, input := range map[string][]byte{
+		"truncated binary": data[:len(data)-3],
+		"invalid json":     []byte("{"),
+		"unknown version":  append([]byte("MSRS"), 99),
+	} {
+		if _, err := UnmarshalSnapshot(input); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSnapshot_AnchorKeepsRemovalStack(t *testing.T) {
+	// O anel restaurado deve reutilizar os buckets livres na mesma ordem do original
+	ring := NewAnchorHashRing(32)
+	for i := 1; i <= 6; i++ {
+		ring.AddNode(fmt.Sprintf("shard%02d", i))
+	}
+	ring.RemoveNode("shard02")
+	ring.RemoveNode("shard05")
+
+	restored, err := NewHashRingFromSnapshot(ring.(interfaces.SnapshotHashRing).Snapshot())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ring.AddNode("shard07")
+	restored.AddNode("shard07")
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if restored.GetNode(key) != ring.GetNode(key) {
+			t.Fatalf("Key %s mapped to %s, original ring mapped to %s", key, restored.GetNode(key), ring.GetNode(key))
+		}
+	}
+}
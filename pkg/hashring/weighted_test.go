@@ -0,0 +1,58 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestConsistentHashRing_AddWeightedNode(t *testing.T) {
+	ring := NewConsistentHashRing(10).(*ConsistentHashRing)
+
+	ring.AddWeightedNode("shard01", 1)
+	ring.AddWeightedNode("shard02", 3)
+
+	counts := make(map[string]int)
+	for _, node := range ring.VirtualNodes() {
+		counts[node.ID]++
+	}
+
+	if counts["shard01"] != 10 {
+		t.Errorf("Expected 10 virtual nodes for shard01, got %d", counts["shard01"])
+	}
+	if counts["shard02"] != 30 {
+		t.Errorf("Expected 30 virtual nodes for shard02, got %d", counts["shard02"])
+	}
+
+	// Peso inválido é tratado como peso 1
+	ring.AddWeightedNode("shard03", 0)
+	counts["shard03"] = 0
+	for _, node := range ring.VirtualNodes() {
+		if node.ID == "shard03" {
+			counts["shard03"]++
+		}
+	}
+	if counts["shard03"] != 10 {
+		t.Errorf("Expected 10 virtual nodes for shard03, got %d", counts["shard03"])
+	}
+}
+
+func TestConsistentHashRing_WeightedDistribution(t *testing.T) {
+	ring := NewConsistentHashRing(200).(*ConsistentHashRing)
+	ring.AddWeightedNode("shard01", 1)
+	ring.AddWeightedNode("shard02", 1)
+	ring.AddWeightedNode("shard03", 2)
+
+	numKeys := 40000
+	distribution := make(map[string]int)
+	for i := 0; i < numKeys; i++ {
+		distribution[ring.GetNode(fmt.Sprintf("tenant-%d", i))]++
+	}
+
+	// shard03 tem o dobro de capacidade e deve receber aproximadamente metade das chaves
+	ratio := float64(distribution["shard03"]) / float64(numKeys)
+	if ratio < 0.4 || ratio > 0.6 {
+		t.Errorf("Expected shard03 to receive around 50%% of keys, got %.1f%%", ratio*100)
+	}
+
+	t.Logf("Distribution: %v", distribution)
+}
@@ -1,33 +1,151 @@
 package interfaces
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
-// HashRing define a interface para operações de hash consistente
+// HashRing define a interface para operações de hash consistente.
+// AddNode retorna erro quando o nó não pode ser adicionado, como em um anel sem capacidade.
 type HashRing interface {
-	AddNode(nodeID string)
+	AddNode(nodeID string) error
+	RemoveNode(nodeID string)
+	ListNodes() []string
 	GetNode(key string) string
+	GetNodes(key string, n int) []string
 	GetHashAlgorithm() string
 }
 
+// LoadAwareHashRing define um hash ring que considera a carga em andamento
+// de cada nó no momento de escolher o destino de uma chave
+type LoadAwareHashRing interface {
+	HashRing
+	IncrementLoad(nodeID string)
+	DecrementLoad(nodeID string)
+}
+
+// WeightedHashRing define um hash ring que aceita nós com capacidades diferentes
+type WeightedHashRing interface {
+	HashRing
+	AddWeightedNode(nodeID string, weight int) error
+}
+
+// SnapshotHashRing define um hash ring capaz de exportar sua topologia completa
+type SnapshotHashRing interface {
+	HashRing
+	Snapshot() RingSnapshot
+}
+
+// RingSnapshot representa a topologia de um hash ring em um instante: algoritmo,
+// impressão digital da seed, parâmetros, nós e os hashes de cada réplica virtual. A seed
+// não é exportada. Dois routers com o mesmo snapshot e a mesma seed roteiam todas as
+// chaves para os mesmos shards.
+type RingSnapshot struct {
+	Generation      uint64         `json:"generation"`
+	Type            string         `json:"type"`
+	HashAlgorithm   string         `json:"hash_algorithm"`
+	SeedFingerprint string         `json:"seed_fingerprint,omitempty"`
+	Replicas        int            `json:"replicas,omitempty"`
+	TableSize       int            `json:"table_size,omitempty"`
+	LoadFactor      float64        `json:"load_factor,omitempty"`
+	Probes          int            `json:"probes,omitempty"`
+	Capacity        int            `json:"capacity,omitempty"`
+	Nodes           []SnapshotNode `json:"nodes"`
+	RemovedBuckets  []int          `json:"removed_buckets,omitempty"`
+}
+
+// SnapshotNode representa um nó do snapshot. Bucket é usado pelos hash rings baseados
+// em buckets (JUMP e ANCHOR) e Hashes contém as posições do nó no anel, em ordem crescente.
+type SnapshotNode struct {
+	ID     string   `json:"id"`
+	Weight int      `json:"weight"`
+	Bucket int      `json:"bucket,omitempty"`
+	Hashes []uint64 `json:"hashes,omitempty"`
+}
+
+// KeyExtractor define a interface para extrair a chave de sharding de uma requisição.
+// Retorna false quando a requisição não contém a chave.
+type KeyExtractor interface {
+	Extract(r *http.Request) (string, bool)
+	String() string
+}
+
+// HashRingConfig define as configurações usadas na criação do hash ring
+type HashRingConfig struct {
+	Type       string
+	Replicas   int
+	TableSize  int
+	LoadFactor float64
+	Probes     int
+	Capacity   int
+}
+
+// ProxyConfig define as configurações do encaminhamento de requisições aos shards.
+// Valores zero utilizam os padrões do pacote proxy. FlushInterval define a frequência
+// de flush do corpo da resposta ao cliente: zero não faz flush periódico e um valor
+// negativo faz flush após cada escrita.
+type ProxyConfig struct {
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	FlushInterval         time.Duration
+	PreserveHost          bool
+	BroadcastBodyLimit    int64
+}
+
+// ServerConfig define como o router aceita conexões dos clientes. Com certificado e chave,
+// o servidor usa TLS e negocia HTTP/2 via ALPN; GRPC habilita HTTP/2 sem TLS (h2c),
+// usado pelos clientes gRPC em texto puro. AdminPort habilita a porta administrativa,
+// separada da porta dos clientes, que exporta o snapshot do hash ring. AdminToken habilita
+// nela as alterações de membros, autenticadas com o token.
+type ServerConfig struct {
+	TLSCertFile string
+	TLSKeyFile  string
+	GRPC        bool
+	AdminPort   string
+	AdminToken  string
+}
+
 // ShardRouter define a interface para roteamento de shards
 type ShardRouter interface {
 	GetShardingKey(r *http.Request) string
+	LookupShardingKey(r *http.Request) (string, bool)
+	MissingKeyPolicy() string
+	GetMissingKeyShards() []string
 	GetShardHost(key string) string
-	InitHashRing(size int)
-	AddShard(shardHost string)
+	GetShardHosts(key string, n int) []string
+	InitHashRing(config HashRingConfig) error
+	InitHashRingFromSnapshot(snapshot RingSnapshot) error
+	Snapshot() (RingSnapshot, error)
+	AddShard(shardHost string) error
+	AddWeightedShard(shardHost string, weight int) error
+	RemoveShard(shardHost string)
+	ListShards() []string
+	StartRequest(shardHost string)
+	FinishRequest(shardHost string)
 }
 
 // ConfigManager define a interface para gerenciamento de configuração
 type ConfigManager interface {
 	LoadShards() ([]Shard, error)
 	GetShardingKey() string
+	GetKeyExtractor() (KeyExtractor, error)
+	GetHashRingConfig() HashRingConfig
+	GetProxyConfig() ProxyConfig
+	GetServerConfig() ServerConfig
+	LoadHashRingSnapshot() (*RingSnapshot, error)
 }
 
 // Shard representa um shard no sistema
 type Shard struct {
-	ID   int
-	Name string
-	URL  string
+	ID     int
+	Name   string
+	URL    string
+	Weight int
 }
 
 // ProxyHandler define a interface para o handler de proxy
@@ -39,4 +157,5 @@ type ProxyHandler interface {
 type MetricsRecorder interface {
 	RecordRequest(shard string)
 	RecordResponse(shard string, statusCode int)
+	RecordMissingKey(policy string)
 }
@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GRPCCode é um código de status gRPC, enviado no header/trailer grpc-status
+type GRPCCode int
+
+// Códigos de status gRPC usados pelo router (google.golang.org/grpc/codes)
+const (
+	GRPCInvalidArgument GRPCCode = 3
+	GRPCInternal        GRPCCode = 13
+	GRPCUnavailable     GRPCCode = 14
+)
+
+// IsGRPC indica se a requisição é uma chamada gRPC (application/grpc ou application/grpc+<formato>).
+// gRPC-Web, que também funciona sobre HTTP/1.1, é tratado como uma requisição comum.
+func IsGRPC(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/grpc" || strings.HasPrefix(mediaType, "application/grpc+")
+}
+
+// GRPCStatus converte o status HTTP de uma falha de roteamento no código gRPC equivalente
+func GRPCStatus(status int) GRPCCode {
+	switch status {
+	case http.StatusBadRequest:
+		return GRPCInvalidArgument
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return GRPCUnavailable
+	default:
+		return GRPCInternal
+	}
+}
+
+// WriteGRPCError responde à chamada gRPC com uma resposta trailers-only: status HTTP 200
+// e grpc-status/grpc-message nos headers, sem mensagens no corpo
+func WriteGRPCError(w http.ResponseWriter, code GRPCCode, message string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", encodeGRPCMessage(message))
+	w.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage aplica o percent-encoding exigido em grpc-message aos bytes fora
+// do intervalo ASCII imprimível e ao próprio '%'
+func encodeGRPCMessage(message string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+			continue
+		}
+		encoded.WriteByte(c)
+	}
+	return encoded.String()
+}
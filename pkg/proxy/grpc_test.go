@@ -0,0 +1,180 @@
+package proxy
+
+import (
+	"app/pkg/interfaces"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsGRPC(t *testing.T) {
+	tests := map[string]bool{
+		"application/grpc":       true,
+		"application/grpc+proto": true,
+		"application/grpc; a=b":  true,
+		"application/grpc-web":   false,
+		"application/json":       false,
+		"":                       false,
+	}
+
+	for contentType, expected := range tests {
+		r := httptest.NewRequest("POST", "/orders.v1.Orders/Get", nil)
+		r.Header.Set("Content-Type", contentType)
+		if got := IsGRPC(r); got != expected {
+			t.Errorf("IsGRPC(%q): expected %v, got %v", contentType, expected, got)
+		}
+	}
+}
+
+func TestWriteGRPCError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	WriteGRPCError(rr, GRPCStatus(http.StatusBadRequest), "missing sharding key: 100% não")
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected HTTP 200 for a trailers-only response, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Type") != "application/grpc" || rr.Header().Get("Grpc-Status") != "3" {
+		t.Errorf("Unexpected headers %v", rr.Header())
+	}
+	if message := rr.Header().Get("Grpc-Message"); message != "missing sharding key: 100%25 n%C3%A3o" {
+		t.Errorf("Expected percent-encoded message, got '%s'", message)
+	}
+	if GRPCStatus(http.StatusBadGateway) != GRPCUnavailable || GRPCStatus(http.StatusInternalServerError) != GRPCInternal {
+		t.Error("Unexpected HTTP to gRPC status mapping")
+	}
+}
+
+// newH2CServer cria um servidor de teste que aceita HTTP/2 sem TLS, como um shard gRPC
+func newH2CServer(t *testing.T, handler http.Handler) *httptest.Server {
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newH2CClient cria um cliente que fala HTTP/2 sem TLS, como um cliente gRPC
+func newH2CClient() *http.Client {
+	transport := &http.Transport{Protocols: new(http.Protocols)}
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	return &http.Client{Transport: transport}
+}
+
+// newGRPCRouter cria um router de teste em h2c que encaminha ao shard pelo Proxy
+func newGRPCRouter(t *testing.T, p *Proxy, shard string) *httptest.Server {
+	return newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := p.NewRequest(r, shard)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp, err := p.RoundTrip(req)
+		if err != nil {
+			WriteGRPCError(w, GRPCUnavailable, err.Error())
+			return
+		}
+		defer resp.Body.Close()
+		p.WriteResponse(w, resp)
+	}))
+}
+
+func TestProxy_GRPCUnary(t *testing.T) {
+	shard := newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.Header.Get("Te") != "trailers" {
+			w.Header().Set("Grpc-Status", "13")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+		w.Header().Set(http.TrailerPrefix+"X-Shard", "shard01")
+	}))
+
+	p := New(interfaces.ProxyConfig{})
+	router := newGRPCRouter(t, p, shard.URL)
+
+	message := []byte{0, 0, 0, 0, 3, 0x0a, 0x01, 'a'}
+	req, _ := http.NewRequest("POST", router.URL+"/orders.v1.Orders/Get", bytes.NewReader(message))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	resp, err := newH2CClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Grpc-Status") != "" {
+		t.Fatalf("Shard did not receive an HTTP/2 call with TE: trailers")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.ProtoMajor != 2 || !bytes.Equal(body, message) {
+		t.Errorf("Expected HTTP/2 echo of the message, got %s %v", resp.Proto, body)
+	}
+	if resp.Trailer.Get("Grpc-Status") != "0" || resp.Trailer.Get("X-Shard") != "shard01" {
+		t.Errorf("Expected trailers to pass through, got %v", resp.Trailer)
+	}
+}
+
+func TestProxy_GRPCBidirectionalStream(t *testing.T) {
+	shard := newH2CServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		buf := make([]byte, 4)
+		for {
+			n, err := io.ReadFull(r.Body, buf)
+			if err != nil {
+				break
+			}
+			w.Write(buf[:n])
+			w.(http.Flusher).Flush()
+		}
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	}))
+
+	p := New(interfaces.ProxyConfig{})
+	router := newGRPCRouter(t, p, shard.URL)
+
+	requestBody, requestWriter := io.Pipe()
+	req, _ := http.NewRequest("POST", router.URL+"/chat.v1.Chat/Stream", requestBody)
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := newH2CClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Cada mensagem precisa voltar antes de a próxima ser enviada
+	for _, message := range []string{"ping", "pong"} {
+		go requestWriter.Write([]byte(message))
+
+		received := make(chan string, 1)
+		go func() {
+			buf := make([]byte, 4)
+			io.ReadFull(resp.Body, buf)
+			received <- string(buf)
+		}()
+		select {
+		case got := <-received:
+			if got != message {
+				t.Errorf("Expected %q, got %q", message, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for streamed message %q", message)
+		}
+	}
+
+	requestWriter.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.Trailer.Get("Grpc-Status") != "0" {
+		t.Errorf("Expected grpc-status trailer after the stream, got %v", resp.Trailer)
+	}
+}
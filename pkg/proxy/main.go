@@ -0,0 +1,547 @@
+package proxy
+
+import (
+	"app/pkg/interfaces"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Valores padrão das configurações de ProxyConfig
+const (
+	DefaultMaxIdleConnsPerHost   = 100
+	DefaultIdleConnTimeout       = 90 * time.Second
+	DefaultDialTimeout           = 5 * time.Second
+	DefaultKeepAlive             = 30 * time.Second
+	DefaultTLSHandshakeTimeout   = 10 * time.Second
+	DefaultResponseHeaderTimeout = 60 * time.Second
+	DefaultBroadcastBodyLimit    = 1 << 20
+)
+
+// ErrUpgrade indica que a troca de protocolo falhou antes de a conexão do cliente ser
+// assumida, quando ainda é possível responder ao cliente com um erro
+var ErrUpgrade = errors.New("protocol switch failed")
+
+// hopHeaders são os headers hop-by-hop (RFC 9110, seção 7.6.1), válidos apenas entre
+// o cliente e o router e, portanto, removidos antes de encaminhar ao shard
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy encaminha requisições aos shards. Cada shard tem o seu próprio http.Transport,
+// compartilhado entre as requisições, de forma que conexões keep-alive são reutilizadas
+// e um shard lento não esgota o pool de conexões dos demais. Chamadas gRPC usam um
+// transport separado, somente HTTP/2, com h2c para shards http://.
+type Proxy struct {
+	config         interfaces.ProxyConfig
+	mu             sync.Mutex
+	transports     map[string]*http.Transport
+	grpcTransports map[string]*http.Transport
+}
+
+// New cria um Proxy com a configuração informada. Valores zero utilizam os padrões.
+func New(config interfaces.ProxyConfig) *Proxy {
+	if config.MaxIdleConnsPerHost <= 0 {
+		config.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout <= 0 {
+		config.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = DefaultDialTimeout
+	}
+	if config.KeepAlive <= 0 {
+		config.KeepAlive = DefaultKeepAlive
+	}
+	if config.TLSHandshakeTimeout <= 0 {
+		config.TLSHandshakeTimeout = DefaultTLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout <= 0 {
+		config.ResponseHeaderTimeout = DefaultResponseHeaderTimeout
+	}
+	if config.BroadcastBodyLimit <= 0 {
+		config.BroadcastBodyLimit = DefaultBroadcastBodyLimit
+	}
+
+	return &Proxy{
+		config:         config,
+		transports:     make(map[string]*http.Transport),
+		grpcTransports: make(map[string]*http.Transport),
+	}
+}
+
+// Config retorna a configuração efetiva, com os valores padrão aplicados
+func (p *Proxy) Config() interfaces.ProxyConfig {
+	return p.config
+}
+
+// Transport retorna o transport do shard, criando-o no primeiro uso
+func (p *Proxy) Transport(shard string) *http.Transport {
+	return p.transport(p.transports, shard, false)
+}
+
+// GRPCTransport retorna o transport HTTP/2 do shard usado pelas chamadas gRPC
+func (p *Proxy) GRPCTransport(shard string) *http.Transport {
+	return p.transport(p.grpcTransports, shard, true)
+}
+
+// transport busca o transport do shard em transports, criando-o no primeiro uso.
+// Com http2Only, shards http:// são acessados com HTTP/2 sem TLS (h2c).
+func (p *Proxy) transport(transports map[string]*http.Transport, shard string, http2Only bool) *http.Transport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if transport, ok := transports[shard]; ok {
+		return transport
+	}
+	dialer := &net.Dialer{
+		Timeout:   p.config.DialTimeout,
+		KeepAlive: p.config.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          p.config.MaxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   p.config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       p.config.MaxConnsPerHost,
+		IdleConnTimeout:       p.config.IdleConnTimeout,
+		TLSHandshakeTimeout:   p.config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: p.config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		// O corpo é repassado como recebido; a descompressão transparente do transport
+		// obrigaria a ler blocos comprimidos inteiros, atrasando respostas em streaming
+		DisableCompression: true,
+	}
+	if http2Only {
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+		// Chamadas de streaming podem durar indefinidamente antes da primeira resposta
+		transport.ResponseHeaderTimeout = 0
+	}
+	transports[shard] = transport
+	return transport
+}
+
+// CloseIdleConnections fecha as conexões ociosas de todos os shards
+func (p *Proxy) CloseIdleConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, transport := range p.transports {
+		transport.CloseIdleConnections()
+	}
+	for _, transport := range p.grpcTransports {
+		transport.CloseIdleConnections()
+	}
+}
+
+// NewRequest cria a requisição para o shard a partir da requisição recebida: o path do
+// shard é combinado com o path e a query string originais, os headers são copiados sem
+// os hop-by-hop e X-Forwarded-For/Proto/Host e Forwarded identificam o cliente.
+// O Host enviado é o do shard, exceto com PreserveHost. Em requisições de upgrade,
+// Connection e Upgrade são mantidos para que o shard possa trocar de protocolo.
+func (p *Proxy) NewRequest(r *http.Request, shardURL string) (*http.Request, error) {
+	target, err := url.Parse(shardURL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid shard URL '%s'", shardURL)
+	}
+	target.Path, target.RawPath = joinURLPath(target, r.URL)
+	target.RawQuery = r.URL.RawQuery
+
+	body := r.Body
+	if r.ContentLength == 0 {
+		body = http.NoBody
+	}
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	out.ContentLength = r.ContentLength
+	out.Header = r.Header.Clone()
+	removeHopHeaders(out.Header)
+	if protocol := upgradeType(r.Header); protocol != "" {
+		out.Header.Set("Connection", "Upgrade")
+		out.Header.Set("Upgrade", protocol)
+		// O upgrade h2c (RFC 7540, seção 3.2) exige HTTP2-Settings, listado também em Connection
+		if settings := r.Header.Get("HTTP2-Settings"); settings != "" {
+			out.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+			out.Header.Set("HTTP2-Settings", settings)
+		}
+	}
+	if p.config.PreserveHost {
+		out.Host = r.Host
+	}
+	setForwardedHeaders(out.Header, r)
+	return out, nil
+}
+
+// RoundTrip envia a requisição pelo transport do shard. Redirecionamentos não são
+// seguidos, sendo repassados ao cliente como qualquer outra resposta.
+func (p *Proxy) RoundTrip(req *http.Request) (*http.Response, error) {
+	shard := req.URL.Scheme + "://" + req.URL.Host
+	if IsGRPC(req) {
+		return p.GRPCTransport(shard).RoundTrip(req)
+	}
+	return p.Transport(shard).RoundTrip(req)
+}
+
+// WriteResponse copia os headers (sem os hop-by-hop), o status, o corpo e os trailers
+// da resposta, fazendo flush ao cliente conforme FlushInterval. Respostas
+// text/event-stream e de tamanho desconhecido (chunked, gRPC) têm flush a cada escrita,
+// para que eventos não se acumulem no buffer. Retorna o erro da cópia do corpo,
+// normalmente causado pela desconexão do cliente.
+func (p *Proxy) WriteResponse(w http.ResponseWriter, resp *http.Response) error {
+	removeHopHeaders(resp.Header)
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	announced := make([]string, 0, len(resp.Trailer))
+	for k := range resp.Trailer {
+		announced = append(announced, k)
+	}
+	if len(announced) > 0 {
+		w.Header().Set("Trailer", strings.Join(announced, ", "))
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	if err := p.copyBody(w, resp); err != nil {
+		return err
+	}
+
+	// resp.Trailer só é preenchido após a leitura completa do corpo. Trailers não
+	// anunciados pelo shard são enviados com o prefixo http.TrailerPrefix.
+	for k, v := range resp.Trailer {
+		if !slices.Contains(announced, k) {
+			k = http.TrailerPrefix + k
+		}
+		w.Header()[k] = v
+	}
+	return nil
+}
+
+// copyBody copia o corpo da resposta com o intervalo de flush adequado
+func (p *Proxy) copyBody(w http.ResponseWriter, resp *http.Response) error {
+	interval := p.flushInterval(resp)
+	if interval == 0 {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	}
+
+	controller := http.NewResponseController(w)
+	if interval < 0 {
+		controller.Flush()
+		_, err := copyBuffer(&flushWriter{w: w, controller: controller}, resp.Body)
+		return err
+	}
+
+	writer := &latencyWriter{w: w, controller: controller, latency: interval}
+	defer writer.stop()
+	_, err := copyBuffer(writer, resp.Body)
+	return err
+}
+
+// flushInterval retorna o intervalo de flush da resposta: negativo para flush imediato
+func (p *Proxy) flushInterval(resp *http.Response) time.Duration {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" || resp.ContentLength == -1 {
+		return -1
+	}
+	return p.config.FlushInterval
+}
+
+// copyBuffer copia o corpo escrevendo cada leitura assim que ela termina, ao contrário
+// de io.Copy, que pode delegar a cópia ao ReadFrom do destino
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var written int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			w, werr := dst.Write(buf[:n])
+			written += int64(w)
+			if werr != nil {
+				return written, werr
+			}
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// flushWriter faz flush ao cliente após cada escrita
+type flushWriter struct {
+	w          io.Writer
+	controller *http.ResponseController
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	if err := f.controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return n, err
+	}
+	return n, nil
+}
+
+// latencyWriter agenda um flush para no máximo latency após a primeira escrita
+// pendente, agrupando as escritas feitas nesse intervalo
+type latencyWriter struct {
+	w          io.Writer
+	controller *http.ResponseController
+	latency    time.Duration
+
+	mu           sync.Mutex
+	timer        *time.Timer
+	flushPending bool
+}
+
+func (l *latencyWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n, err := l.w.Write(p)
+	if l.flushPending {
+		return n, err
+	}
+	if l.timer == nil {
+		l.timer = time.AfterFunc(l.latency, l.delayedFlush)
+	} else {
+		l.timer.Reset(l.latency)
+	}
+	l.flushPending = true
+	return n, err
+}
+
+func (l *latencyWriter) delayedFlush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// stop pode ter sido chamado enquanto o timer disparava
+	if !l.flushPending {
+		return
+	}
+	l.controller.Flush()
+	l.flushPending = false
+}
+
+// stop cancela o flush pendente; o servidor HTTP faz o flush final ao fim do handler
+func (l *latencyWriter) stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flushPending = false
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// IsUpgrade indica se a requisição pede a troca de protocolo (WebSocket, h2c etc.)
+func IsUpgrade(r *http.Request) bool {
+	return upgradeType(r.Header) != ""
+}
+
+// HandleUpgrade completa uma troca de protocolo aceita pelo shard (101 Switching Protocols):
+// a conexão do cliente é assumida (hijack), recebe a resposta do shard e passa a ser ligada
+// à conexão com o shard, copiando bytes nos dois sentidos até que um dos lados a encerre.
+// Erros anteriores ao hijack são do tipo ErrUpgrade e podem ser respondidos ao cliente.
+func (p *Proxy) HandleUpgrade(w http.ResponseWriter, r *http.Request, resp *http.Response) error {
+	requested, switched := upgradeType(r.Header), upgradeType(resp.Header)
+	if !strings.EqualFold(requested, switched) {
+		return fmt.Errorf("%w: shard switched to protocol '%s' when '%s' was requested", ErrUpgrade, switched, requested)
+	}
+	backConn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		return fmt.Errorf("%w: shard connection does not support protocol switching", ErrUpgrade)
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fmt.Errorf("%w: failed to hijack client connection: %v", ErrUpgrade, err)
+	}
+	defer conn.Close()
+
+	// O cancelamento da requisição, como no desligamento do servidor, encerra o túnel
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+		backConn.Close()
+	}()
+
+	removeHopHeaders(resp.Header)
+	resp.Header.Set("Connection", "Upgrade")
+	resp.Header.Set("Upgrade", switched)
+	head := *resp
+	head.Body = nil
+	if err := head.Write(brw); err != nil {
+		return err
+	}
+	if err := brw.Flush(); err != nil {
+		return err
+	}
+
+	// brw.Reader pode conter bytes já enviados pelo cliente após a requisição
+	errc := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(backConn, brw.Reader)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, backConn)
+		errc <- err
+	}()
+
+	err = <-errc
+	conn.Close()
+	backConn.Close()
+	<-errc
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// upgradeType retorna o protocolo pedido em Upgrade quando Connection contém o token upgrade
+func upgradeType(header http.Header) string {
+	for _, value := range header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return header.Get("Upgrade")
+			}
+		}
+	}
+	return ""
+}
+
+// removeHopHeaders remove os headers hop-by-hop e os listados no header Connection.
+// "TE: trailers" é mantido, pois indica suporte a trailers de ponta a ponta.
+func removeHopHeaders(header http.Header) {
+	for _, value := range header.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				header.Del(name)
+			}
+		}
+	}
+
+	trailers := false
+	for _, value := range header.Values("Te") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "trailers") {
+				trailers = true
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+	if trailers {
+		header.Set("Te", "trailers")
+	}
+}
+
+// setForwardedHeaders acrescenta o cliente a X-Forwarded-For e Forwarded (RFC 7239).
+// X-Forwarded-Host e X-Forwarded-Proto recebidos de um proxy anterior são mantidos.
+func setForwardedHeaders(header http.Header, r *http.Request) {
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+	clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		clientIP = r.RemoteAddr
+	}
+
+	if clientIP != "" {
+		if prior := header.Values("X-Forwarded-For"); len(prior) > 0 {
+			header.Set("X-Forwarded-For", strings.Join(prior, ", ")+", "+clientIP)
+		} else {
+			header.Set("X-Forwarded-For", clientIP)
+		}
+	}
+	if header.Get("X-Forwarded-Host") == "" && r.Host != "" {
+		header.Set("X-Forwarded-Host", r.Host)
+	}
+	if header.Get("X-Forwarded-Proto") == "" {
+		header.Set("X-Forwarded-Proto", proto)
+	}
+
+	element := "proto=" + proto
+	if clientIP != "" {
+		element = "for=" + forwardedNode(clientIP) + ";" + element
+	}
+	if r.Host != "" {
+		element += ";host=" + forwardedValue(r.Host)
+	}
+	if prior := header.Values("Forwarded"); len(prior) > 0 {
+		header.Set("Forwarded", strings.Join(prior, ", ")+", "+element)
+	} else {
+		header.Set("Forwarded", element)
+	}
+}
+
+// forwardedNode formata o endereço do cliente; IPv6 fica entre colchetes e aspas
+func forwardedNode(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `"[` + ip + `]"`
+	}
+	return ip
+}
+
+// forwardedValue coloca entre aspas valores que não são tokens, como host:porta
+func forwardedValue(value string) string {
+	if strings.ContainsAny(value, `:[]" `) {
+		return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	return value
+}
+
+// joinURLPath combina o path do shard com o path da requisição, preservando a forma
+// escapada original (RawPath) quando existir
+func joinURLPath(target, request *url.URL) (path, rawPath string) {
+	if target.RawPath == "" && request.RawPath == "" {
+		return singleJoiningSlash(target.Path, request.Path), ""
+	}
+	return singleJoiningSlash(target.Path, request.Path),
+		singleJoiningSlash(target.EscapedPath(), request.EscapedPath())
+}
+
+// singleJoiningSlash une dois trechos de path com exatamente uma barra entre eles
+func singleJoiningSlash(a, b string) string {
+	aslash := strings.HasSuffix(a, "/")
+	bslash := strings.HasPrefix(b, "/")
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash && b != "":
+		return a + "/" + b
+	}
+	return a + b
+}
@@ -0,0 +1,483 @@
+package proxy
+
+import (
+	"app/pkg/interfaces"
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNew_Defaults(t *testing.T) {
+	config := New(interfaces.ProxyConfig{DialTimeout: time.Second}).Config()
+
+	if config.DialTimeout != time.Second {
+		t.Errorf("Expected configured dial timeout to be kept, got %v", config.DialTimeout)
+	}
+	if config.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || config.IdleConnTimeout != DefaultIdleConnTimeout ||
+		config.ResponseHeaderTimeout != DefaultResponseHeaderTimeout {
+		t.Errorf("Expected defaults to be applied, got %+v", config)
+	}
+}
+
+func TestProxy_NewRequest(t *testing.T) {
+	p := New(interfaces.ProxyConfig{})
+
+	r := httptest.NewRequest("POST", "http://router.example.com/orders/a%2Fb?tenant=acme&page=2", strings.NewReader("body"))
+	r.RemoteAddr = "203.0.113.7:52000"
+	r.Header.Set("X-Custom", "value")
+	r.Header.Set("Connection", "keep-alive, X-Hop")
+	r.Header.Set("X-Hop", "remove-me")
+	r.Header.Set("Keep-Alive", "timeout=5")
+	r.Header.Set("Proxy-Authorization", "Basic secret")
+	r.Header.Set("Te", "trailers, deflate")
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	out, err := p.NewRequest(r, "http://shard01:8080/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := out.URL.String(); got != "http://shard01:8080/api/orders/a%2Fb?tenant=acme&page=2" {
+		t.Errorf("Unexpected target URL %s", got)
+	}
+	if out.Host != "" && out.Host != "shard01:8080" {
+		t.Errorf("Expected shard host, got %s", out.Host)
+	}
+	if out.Header.Get("X-Custom") != "value" {
+		t.Error("Expected end-to-end headers to be forwarded")
+	}
+	for _, name := range []string{"Connection", "X-Hop", "Keep-Alive", "Proxy-Authorization"} {
+		if out.Header.Get(name) != "" {
+			t.Errorf("Expected hop-by-hop header %s to be removed", name)
+		}
+	}
+	if out.Header.Get("Te") != "trailers" {
+		t.Errorf("Expected 'TE: trailers' to be kept, got '%s'", out.Header.Get("Te"))
+	}
+	if got := out.Header.Get("X-Forwarded-For"); got != "198.51.100.1, 203.0.113.7" {
+		t.Errorf("Unexpected X-Forwarded-For '%s'", got)
+	}
+	if out.Header.Get("X-Forwarded-Host") != "router.example.com" || out.Header.Get("X-Forwarded-Proto") != "http" {
+		t.Errorf("Unexpected X-Forwarded-Host/Proto: %s/%s", out.Header.Get("X-Forwarded-Host"), out.Header.Get("X-Forwarded-Proto"))
+	}
+	if got := out.Header.Get("Forwarded"); got != "for=203.0.113.7;proto=http;host=router.example.com" {
+		t.Errorf("Unexpected Forwarded '%s'", got)
+	}
+
+	// O header da requisição original não deve ser alterado
+	if r.Header.Get("Connection") == "" || r.Header.Get("X-Forwarded-For") != "198.51.100.1" {
+		t.Error("Inbound request headers were modified")
+	}
+}
+
+func TestProxy_NewRequest_ForwardedChain(t *testing.T) {
+	p := New(interfaces.ProxyConfig{PreserveHost: true})
+
+	r := httptest.NewRequest("GET", "http://api.example.com:8443/", nil)
+	r.RemoteAddr = "[2001:db8::1]:443"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("Forwarded", "for=198.51.100.1")
+
+	out, err := p.NewRequest(r, "http://shard01:80")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Host != "api.example.com:8443" {
+		t.Errorf("Expected inbound host with PreserveHost, got '%s'", out.Host)
+	}
+	if out.Header.Get("X-Forwarded-Proto") != "https" {
+		t.Error("Expected X-Forwarded-Proto from previous proxy to be kept")
+	}
+	expected := `for=198.51.100.1, for="[2001:db8::1]";proto=http;host="api.example.com:8443"`
+	if got := out.Header.Get("Forwarded"); got != expected {
+		t.Errorf("Expected Forwarded '%s', got '%s'", expected, got)
+	}
+	if out.ContentLength != 0 || out.Body != http.NoBody {
+		t.Error("Expected empty body for request without content")
+	}
+}
+
+func TestProxy_NewRequest_InvalidShard(t *testing.T) {
+	p := New(interfaces.ProxyConfig{})
+	r := httptest.NewRequest("GET", "/", nil)
+
+	for _, shard := range []string{"ht!tp://invalid-url", "shard01:80", ""} {
+		if _, err := p.NewRequest(r, shard); err == nil {
+			t.Errorf("Expected error for shard URL '%s'", shard)
+		}
+	}
+}
+
+func TestProxy_RoundTrip_ReusesConnections(t *testing.T) {
+	var connections atomic.Int32
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	backend.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+
+	p := New(interfaces.ProxyConfig{})
+	for i := 0; i < 10; i++ {
+		out, err := p.NewRequest(httptest.NewRequest("GET", "/", nil), backend.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := p.RoundTrip(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if n := connections.Load(); n != 1 {
+		t.Errorf("Expected sequential requests to reuse 1 connection, got %d", n)
+	}
+	if p.Transport(backend.URL) != p.Transport(backend.URL) || p.Transport(backend.URL) == p.Transport("http://other:80") {
+		t.Error("Expected one shared transport per shard")
+	}
+	p.CloseIdleConnections()
+}
+
+func TestProxy_RoundTrip_DoesNotFollowRedirects(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer backend.Close()
+
+	p := New(interfaces.ProxyConfig{})
+	out, _ := p.NewRequest(httptest.NewRequest("GET", "/", nil), backend.URL)
+	resp, err := p.RoundTrip(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected redirect to be returned to the client, got %d", resp.StatusCode)
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header: http.Header{
+			"Content-Type": {"text/plain"},
+			"Connection":   {"X-Internal"},
+			"X-Internal":   {"secret"},
+			"Keep-Alive":   {"timeout=5"},
+		},
+		Body: io.NopCloser(strings.NewReader("created")),
+	}
+
+	rr := httptest.NewRecorder()
+	if err := New(interfaces.ProxyConfig{}).WriteResponse(rr, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.Code != http.StatusCreated || rr.Body.String() != "created" {
+		t.Errorf("Unexpected response %d '%s'", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "text/plain" {
+		t.Error("Expected end-to-end headers to be copied")
+	}
+	for _, name := range []string{"Connection", "X-Internal", "Keep-Alive"} {
+		if rr.Header().Get(name) != "" {
+			t.Errorf("Expected hop-by-hop header %s to be removed", name)
+		}
+	}
+}
+
+func TestProxy_FlushInterval(t *testing.T) {
+	p := New(interfaces.ProxyConfig{FlushInterval: 100 * time.Millisecond})
+
+	tests := []struct {
+		name          string
+		contentType   string
+		contentLength int64
+		expected      time.Duration
+	}{
+		{"known length", "application/json", 42, 100 * time.Millisecond},
+		{"event stream", "text/event-stream; charset=utf-8", 42, -1},
+		{"unknown length", "application/json", -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{"Content-Type": {tt.contentType}}, ContentLength: tt.contentLength}
+			if got := p.flushInterval(resp); got != tt.expected {
+				t.Errorf("Expected flush interval %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// newStreamingRouter cria um router de teste que encaminha tudo ao shard pelo Proxy
+func newStreamingRouter(t *testing.T, p *Proxy, shard string) *httptest.Server {
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := p.NewRequest(r, shard)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp, err := p.RoundTrip(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		p.WriteResponse(w, resp)
+	}))
+	t.Cleanup(router.Close)
+	return router
+}
+
+// readFirstLine faz um GET e retorna a primeira linha do corpo, falhando se ela não
+// chegar antes do timeout, ou seja, se o router reteve a resposta em buffer
+func readFirstLine(t *testing.T, url string) string {
+	line := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			line <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		value, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		line <- value
+	}()
+	select {
+	case value := <-line:
+		return value
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for streamed data")
+		return ""
+	}
+}
+
+func TestProxy_WriteResponse_StreamsEvents(t *testing.T) {
+	release := make(chan struct{})
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "data: second\n\n")
+	}))
+	defer shard.Close()
+	defer close(release)
+
+	p := New(interfaces.ProxyConfig{})
+	router := newStreamingRouter(t, p, shard.URL)
+
+	// O primeiro evento precisa chegar enquanto o shard ainda mantém a resposta aberta
+	if line := readFirstLine(t, router.URL+"/events"); line != "data: first\n" {
+		t.Errorf("Expected first event, got %q", line)
+	}
+}
+
+func TestProxy_WriteResponse_PeriodicFlush(t *testing.T) {
+	release := make(chan struct{})
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "12")
+		io.WriteString(w, "partial\n")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "end\n")
+	}))
+	defer shard.Close()
+	defer close(release)
+
+	p := New(interfaces.ProxyConfig{FlushInterval: 10 * time.Millisecond})
+	router := newStreamingRouter(t, p, shard.URL)
+
+	if line := readFirstLine(t, router.URL); line != "partial\n" {
+		t.Errorf("Expected partial body to be flushed, got %q", line)
+	}
+}
+
+func TestProxy_NewRequest_Upgrade(t *testing.T) {
+	p := New(interfaces.ProxyConfig{})
+
+	r := httptest.NewRequest("GET", "http://router.example.com/ws", nil)
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+	out, err := p.NewRequest(r, "http://shard01:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Header.Get("Connection") != "Upgrade" || out.Header.Get("Upgrade") != "websocket" {
+		t.Errorf("Expected upgrade headers to be kept, got %v", out.Header)
+	}
+	if out.Header.Get("Sec-WebSocket-Key") == "" {
+		t.Error("Expected end-to-end headers to be copied")
+	}
+
+	r.Header.Set("Connection", "keep-alive")
+	out, err = p.NewRequest(r, "http://shard01:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Header.Get("Upgrade") != "" {
+		t.Error("Expected Upgrade without Connection: Upgrade to be removed")
+	}
+
+	// Upgrade h2c: HTTP2-Settings é mantido e listado novamente em Connection
+	h2c := httptest.NewRequest("GET", "http://router.example.com/", nil)
+	h2c.Header.Set("Connection", "Upgrade, HTTP2-Settings")
+	h2c.Header.Set("Upgrade", "h2c")
+	h2c.Header.Set("HTTP2-Settings", "AAMAAABkAARAAAAAAAIAAAAA")
+	out, err = p.NewRequest(h2c, "http://shard01:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Header.Get("Connection") != "Upgrade, HTTP2-Settings" || out.Header.Get("Upgrade") != "h2c" {
+		t.Errorf("Expected h2c upgrade headers to be kept, got %v", out.Header)
+	}
+	if out.Header.Get("HTTP2-Settings") != "AAMAAABkAARAAAAAAAIAAAAA" {
+		t.Errorf("Expected HTTP2-Settings to be kept, got %v", out.Header)
+	}
+}
+
+// newUpgradeShard cria um shard que aceita o protocolo informado e devolve os bytes recebidos
+func newUpgradeShard(t *testing.T, protocol string) *httptest.Server {
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "echo" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: "+protocol+"\r\n\r\n")
+		io.Copy(conn, brw)
+	}))
+	t.Cleanup(shard.Close)
+	return shard
+}
+
+// newUpgradeRouter cria um router de teste que encaminha upgrades ao shard pelo Proxy
+func newUpgradeRouter(t *testing.T, p *Proxy, shard string) *httptest.Server {
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := p.NewRequest(r, shard)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp, err := p.RoundTrip(req)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		if err := p.HandleUpgrade(w, r, resp); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(router.Close)
+	return router
+}
+
+func TestProxy_HandleUpgrade(t *testing.T) {
+	p := New(interfaces.ProxyConfig{})
+	router := newUpgradeRouter(t, p, newUpgradeShard(t, "echo").URL)
+
+	conn, err := net.Dial("tcp", router.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: router\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Upgrade") != "echo" {
+		t.Fatalf("Expected switch to echo protocol, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	for _, message := range []string{"hello\n", "world\n"} {
+		io.WriteString(conn, message)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != message {
+			t.Errorf("Expected echo %q, got %q", message, line)
+		}
+	}
+}
+
+func TestProxy_HandleUpgrade_H2C(t *testing.T) {
+	// O shard só aceita o upgrade h2c quando recebe HTTP2-Settings
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "h2c" || r.Header.Get("HTTP2-Settings") == "" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n")
+	}))
+	t.Cleanup(shard.Close)
+
+	p := New(interfaces.ProxyConfig{})
+	router := newUpgradeRouter(t, p, shard.URL)
+
+	conn, err := net.Dial("tcp", router.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: router\r\nConnection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAARAAAAAAAIAAAAA\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Upgrade") != "h2c" {
+		t.Errorf("Expected switch to h2c, got %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestProxy_HandleUpgrade_ProtocolMismatch(t *testing.T) {
+	p := New(interfaces.ProxyConfig{})
+	router := newUpgradeRouter(t, p, newUpgradeShard(t, "other").URL)
+
+	req, _ := http.NewRequest("GET", router.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("Expected 502 when the shard switches to another protocol, got %d", resp.StatusCode)
+	}
+}
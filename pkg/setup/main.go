@@ -1,13 +1,18 @@
 package setup
 
 import (
+	"app/pkg/extractor"
+	"app/pkg/hashring"
 	"app/pkg/interfaces"
 	"app/pkg/sharding"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"sync"
+	"time"
 )
 
 // ConfigManagerImpl implementa a interface ConfigManager
@@ -40,6 +45,142 @@ func (cm *ConfigManagerImpl) GetShardingKey() string {
 	return cm.shardingKey
 }
 
+// GetKeyExtractor cria o extrator da chave de sharding a partir de SHARDING_KEY.
+// É o único ponto de configuração da chave, usado pelo servidor e pela inicialização do router.
+func (cm *ConfigManagerImpl) GetKeyExtractor() (interfaces.KeyExtractor, error) {
+	shardingKey := cm.GetShardingKey()
+	if shardingKey == "" {
+		return nil, fmt.Errorf("SHARDING_KEY not set")
+	}
+	keyExtractor, err := extractor.Parse(shardingKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHARDING_KEY: %w", err)
+	}
+	return keyExtractor, nil
+}
+
+// LoadHashRingSnapshot lê o snapshot do hash ring (JSON ou binário) do arquivo definido
+// em HASH_RING_SNAPSHOT. Retorna nil quando a variável não está definida.
+func (cm *ConfigManagerImpl) LoadHashRingSnapshot() (*interfaces.RingSnapshot, error) {
+	path := os.Getenv("HASH_RING_SNAPSHOT")
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HASH_RING_SNAPSHOT: %w", err)
+	}
+	snapshot, err := hashring.UnmarshalSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetHashRingConfig retorna a configuração do hash ring a partir das variáveis de ambiente.
+// O número de réplicas virtuais vem de HASH_RING_VNODES e não depende do número de shards.
+func (cm *ConfigManagerImpl) GetHashRingConfig() interfaces.HashRingConfig {
+	replicas := getEnvInt("HASH_RING_VNODES")
+	if replicas <= 0 {
+		replicas = hashring.DefaultVirtualNodes
+	}
+
+	return interfaces.HashRingConfig{
+		Type:       os.Getenv("HASH_RING_TYPE"),
+		Replicas:   replicas,
+		TableSize:  getEnvInt("HASH_RING_TABLE_SIZE"),
+		LoadFactor: getEnvFloat("HASH_RING_LOAD_FACTOR"),
+		Probes:     getEnvInt("HASH_RING_PROBES"),
+		Capacity:   getEnvInt("HASH_RING_CAPACITY"),
+	}
+}
+
+// GetProxyConfig retorna a configuração do encaminhamento aos shards a partir das variáveis
+// PROXY_*. Durações usam o formato do Go (500ms, 30s, 2m) e valores ausentes utilizam os padrões.
+func (cm *ConfigManagerImpl) GetProxyConfig() interfaces.ProxyConfig {
+	return interfaces.ProxyConfig{
+		MaxIdleConnsPerHost:   getEnvInt("PROXY_MAX_IDLE_CONNS_PER_HOST"),
+		MaxConnsPerHost:       getEnvInt("PROXY_MAX_CONNS_PER_HOST"),
+		IdleConnTimeout:       getEnvDuration("PROXY_IDLE_CONN_TIMEOUT"),
+		DialTimeout:           getEnvDuration("PROXY_DIAL_TIMEOUT"),
+		KeepAlive:             getEnvDuration("PROXY_KEEP_ALIVE"),
+		TLSHandshakeTimeout:   getEnvDuration("PROXY_TLS_HANDSHAKE_TIMEOUT"),
+		ResponseHeaderTimeout: getEnvDuration("PROXY_RESPONSE_HEADER_TIMEOUT"),
+		FlushInterval:         getEnvDuration("PROXY_FLUSH_INTERVAL"),
+		PreserveHost:          getEnvBool("PROXY_PRESERVE_HOST"),
+		BroadcastBodyLimit:    int64(getEnvInt("PROXY_BROADCAST_BODY_LIMIT")),
+	}
+}
+
+// GetServerConfig retorna a configuração do servidor a partir de ROUTER_TLS_CERT,
+// ROUTER_TLS_KEY, ROUTER_GRPC, ROUTER_ADMIN_PORT e ROUTER_ADMIN_TOKEN
+func (cm *ConfigManagerImpl) GetServerConfig() interfaces.ServerConfig {
+	return interfaces.ServerConfig{
+		TLSCertFile: os.Getenv("ROUTER_TLS_CERT"),
+		TLSKeyFile:  os.Getenv("ROUTER_TLS_KEY"),
+		GRPC:        getEnvBool("ROUTER_GRPC"),
+		AdminPort:   os.Getenv("ROUTER_ADMIN_PORT"),
+		AdminToken:  os.Getenv("ROUTER_ADMIN_TOKEN"),
+	}
+}
+
+// getEnvInt lê uma variável de ambiente numérica, retornando zero quando ausente ou inválida
+func getEnvInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		fmt.Printf("Invalid value '%s' for %s, ignoring\n", value, name)
+		return 0
+	}
+	return parsed
+}
+
+// getEnvFloat lê uma variável de ambiente decimal, retornando zero quando ausente ou inválida
+func getEnvFloat(name string) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		fmt.Printf("Invalid value '%s' for %s, ignoring\n", value, name)
+		return 0
+	}
+	return parsed
+}
+
+// getEnvDuration lê uma variável de ambiente de duração, retornando zero quando ausente ou inválida
+func getEnvDuration(name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("Invalid value '%s' for %s, ignoring\n", value, name)
+		return 0
+	}
+	return parsed
+}
+
+// getEnvBool lê uma variável de ambiente booleana, retornando false quando ausente ou inválida
+func getEnvBool(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		fmt.Printf("Invalid value '%s' for %s, ignoring\n", value, name)
+		return false
+	}
+	return parsed
+}
+
 func (cm *ConfigManagerImpl) discoverShards() ([]interfaces.Shard, error) {
 	var shards []interfaces.Shard
 
@@ -58,13 +199,19 @@ func (cm *ConfigManagerImpl) discoverShards() ([]interfaces.Shard, error) {
 				return nil, fmt.Errorf("invalid shard ID %s: %v", matches[1], err)
 			}
 
+			weight, err := shardWeight(matches[1])
+			if err != nil {
+				return nil, err
+			}
+
 			shard := interfaces.Shard{
-				ID:   shardID,
-				Name: "SHARD_" + matches[1],
-				URL:  pair[1],
+				ID:     shardID,
+				Name:   "SHARD_" + matches[1],
+				URL:    pair[1],
+				Weight: weight,
 			}
 			shards = append(shards, shard)
-			fmt.Printf("Mapping shard %v on host: %s\n", shard.ID, shard.URL)
+			fmt.Printf("Mapping shard %v on host: %s (weight %d)\n", shard.ID, shard.URL, shard.Weight)
 		}
 	}
 
@@ -72,9 +219,29 @@ func (cm *ConfigManagerImpl) discoverShards() ([]interfaces.Shard, error) {
 		return nil, fmt.Errorf("no shards found. Please set SHARD_*_URL environment variables")
 	}
 
+	// Ordena pelo ID para que a ordem dos shards não dependa de os.Environ,
+	// já que algoritmos baseados em buckets (ex: Jump Hash) dependem dessa ordem
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i].ID < shards[j].ID
+	})
+
 	return shards, nil
 }
 
+// shardWeight lê o peso do shard na variável SHARD_<N>_WEIGHT, com padrão 1
+func shardWeight(shardID string) (int, error) {
+	name := "SHARD_" + shardID + "_WEIGHT"
+	value := os.Getenv(name)
+	if value == "" {
+		return 1, nil
+	}
+	weight, err := strconv.Atoi(value)
+	if err != nil || weight < 1 {
+		return 0, fmt.Errorf("invalid %s '%s': must be a positive integer", name, value)
+	}
+	return weight, nil
+}
+
 func splitEnv(env string) []string {
 	for i := 0; i < len(env); i++ {
 		if env[i] == '=' {
@@ -87,35 +254,69 @@ func splitEnv(env string) []string {
 // Init inicializa o sistema com as configurações descobertas
 // Esta função mantém compatibilidade com o código existente
 func Init() error {
-	return InitWithRouter(nil)
+	return InitWithRouter(NewConfigManager(), nil)
 }
 
-// InitWithRouter permite injeção de dependência do ShardRouter
-func InitWithRouter(router interfaces.ShardRouter) error {
-	configManager := NewConfigManager()
-
-	shardingKey := configManager.GetShardingKey()
-	if shardingKey == "" {
-		return fmt.Errorf("SHARDING_KEY not set")
+// InitWithRouter inicializa o hash ring do router com a configuração de configManager,
+// permitindo injeção de dependência do ShardRouter. Um router fornecido já tem o extrator
+// da chave, portanto SHARDING_KEY só é lido quando o router é criado aqui.
+func InitWithRouter(configManager interfaces.ConfigManager, router interfaces.ShardRouter) error {
+	// Se não foi fornecido um router, criar um novo
+	if router == nil {
+		keyExtractor, err := configManager.GetKeyExtractor()
+		if err != nil {
+			return err
+		}
+		router, err = sharding.NewShardRouterWithExtractor(keyExtractor)
+		if err != nil {
+			return err
+		}
 	}
 
-	shards, err := configManager.LoadShards()
+	// Um snapshot define toda a topologia, dispensando a descoberta de shards
+	snapshot, err := configManager.LoadHashRingSnapshot()
 	if err != nil {
 		return err
 	}
+	if snapshot != nil {
+		fmt.Printf("Setting up Hash Ring from snapshot %s\n", os.Getenv("HASH_RING_SNAPSHOT"))
+		if err := router.InitHashRingFromSnapshot(*snapshot); err != nil {
+			return err
+		}
+		return checkDefaultShard(router)
+	}
 
-	// Se não foi fornecido um router, criar um novo
-	if router == nil {
-		router = sharding.NewShardRouter(shardingKey)
+	shards, err := configManager.LoadShards()
+	if err != nil {
+		return err
 	}
 
 	// Setup Hash Ring
-	fmt.Printf("Setting up Hash Ring with %v nodes\n", len(shards))
-	router.InitHashRing(len(shards))
+	hashRingConfig := configManager.GetHashRingConfig()
+
+	fmt.Printf("Setting up Hash Ring with %v nodes and %v virtual nodes per shard\n", len(shards), hashRingConfig.Replicas)
+	if err := router.InitHashRing(hashRingConfig); err != nil {
+		return err
+	}
 
 	for _, shard := range shards {
-		router.AddShard(shard.URL)
+		if err := router.AddWeightedShard(shard.URL, shard.Weight); err != nil {
+			return err
+		}
 	}
 
+	return checkDefaultShard(router)
+}
+
+// checkDefaultShard verifica que o shard da política default:<url> está no hash ring,
+// evitando que um erro de digitação envie as requisições sem chave a um host inexistente
+func checkDefaultShard(router interfaces.ShardRouter) error {
+	if router.MissingKeyPolicy() != sharding.MissingKeyDefault {
+		return nil
+	}
+	defaultShard := router.GetMissingKeyShards()[0]
+	if !slices.Contains(router.ListShards(), defaultShard) {
+		return fmt.Errorf("SHARDING_MISSING_KEY_POLICY default shard '%s' is not one of the configured shards", defaultShard)
+	}
 	return nil
 }
@@ -1,34 +1,87 @@
 package setup
 
 import (
+	"app/pkg/hashring"
 	"app/pkg/interfaces"
+	"errors"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 // MockShardRouter é um mock da interface ShardRouter para testes
 type MockShardRouter struct {
-	hashRingSize int
-	shards       []string
-	initCalled   bool
-	getNodeFunc  func(key string) string
+	hashRingSize   int
+	hashRingConfig interfaces.HashRingConfig
+	shards         []string
+	weights        map[string]int
+	initCalled     bool
+	snapshot       *interfaces.RingSnapshot
+	getNodeFunc    func(key string) string
 }
 
-func (m *MockShardRouter) InitHashRing(size int) {
-	m.hashRingSize = size
+func (m *MockShardRouter) InitHashRing(config interfaces.HashRingConfig) error {
+	m.hashRingSize = config.Replicas
+	m.hashRingConfig = config
 	m.initCalled = true
+	return nil
 }
 
-func (m *MockShardRouter) AddShard(shardHost string) {
+func (m *MockShardRouter) InitHashRingFromSnapshot(snapshot interfaces.RingSnapshot) error {
+	m.snapshot = &snapshot
+	m.initCalled = true
+	return nil
+}
+
+func (m *MockShardRouter) Snapshot() (interfaces.RingSnapshot, error) {
+	if m.snapshot == nil {
+		return interfaces.RingSnapshot{}, nil
+	}
+	return *m.snapshot, nil
+}
+
+func (m *MockShardRouter) AddShard(shardHost string) error {
 	m.shards = append(m.shards, shardHost)
+	return nil
+}
+
+func (m *MockShardRouter) AddWeightedShard(shardHost string, weight int) error {
+	if m.weights == nil {
+		m.weights = make(map[string]int)
+	}
+	m.weights[shardHost] = weight
+	return m.AddShard(shardHost)
+}
+
+func (m *MockShardRouter) RemoveShard(shardHost string) {}
+
+func (m *MockShardRouter) ListShards() []string {
+	return m.shards
 }
 
+func (m *MockShardRouter) StartRequest(shardHost string) {}
+
+func (m *MockShardRouter) FinishRequest(shardHost string) {}
+
 func (m *MockShardRouter) GetShardingKey(r *http.Request) string {
 	// Mock implementation - not needed for setup tests
 	return ""
 }
 
+func (m *MockShardRouter) LookupShardingKey(r *http.Request) (string, bool) {
+	return "", false
+}
+
+func (m *MockShardRouter) MissingKeyPolicy() string {
+	return ""
+}
+
+func (m *MockShardRouter) GetMissingKeyShards() []string {
+	return []string{}
+}
+
 func (m *MockShardRouter) GetShardHost(key string) string {
 	if m.getNodeFunc != nil {
 		return m.getNodeFunc(key)
@@ -36,6 +89,10 @@ func (m *MockShardRouter) GetShardHost(key string) string {
 	return ""
 }
 
+func (m *MockShardRouter) GetShardHosts(key string, n int) []string {
+	return []string{m.GetShardHost(key)}
+}
+
 // Garantir que MockShardRouter implementa a interface
 var _ interfaces.ShardRouter = (*MockShardRouter)(nil)
 
@@ -82,6 +139,39 @@ func TestConfigManagerImpl_GetShardingKey(t *testing.T) {
 	}
 }
 
+func TestConfigManagerImpl_GetKeyExtractor(t *testing.T) {
+	tests := []struct {
+		envValue string
+		expected string
+		wantErr  bool
+	}{
+		{envValue: "user_id", expected: "header:user_id"},
+		{envValue: `header:x-region + "-" + query:tenant | cookie:tenant`, expected: `header:x-region + "-" + query:tenant | cookie:tenant`},
+		{envValue: "", wantErr: true},
+		{envValue: "path:first", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.envValue, func(t *testing.T) {
+			t.Setenv("SHARDING_KEY", tt.envValue)
+
+			keyExtractor, err := NewConfigManager().GetKeyExtractor()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keyExtractor.String() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, keyExtractor.String())
+			}
+		})
+	}
+}
+
 func TestConfigManagerImpl_LoadShards(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -207,6 +297,207 @@ func TestConfigManagerImpl_LoadShards_OnlyOnce(t *testing.T) {
 	}
 }
 
+func TestConfigManagerImpl_LoadShards_SortedByID(t *testing.T) {
+	clearShardEnvVars()
+	os.Setenv("SHARD_10_URL", "http://shard10:80")
+	os.Setenv("SHARD_02_URL", "http://shard02:80")
+	os.Setenv("SHARD_01_URL", "http://shard01:80")
+	defer func() {
+		os.Unsetenv("SHARD_10_URL")
+		os.Unsetenv("SHARD_02_URL")
+		os.Unsetenv("SHARD_01_URL")
+	}()
+
+	shards, err := NewConfigManager().LoadShards()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedIDs := []int{1, 2, 10}
+	if len(shards) != len(expectedIDs) {
+		t.Fatalf("Expected %d shards, got %d", len(expectedIDs), len(shards))
+	}
+	for i, id := range expectedIDs {
+		if shards[i].ID != id {
+			t.Errorf("Expected shard at position %d to have ID %d, got %d", i, id, shards[i].ID)
+		}
+	}
+}
+
+func TestConfigManagerImpl_LoadShards_Weights(t *testing.T) {
+	tests := []struct {
+		name           string
+		weight         string
+		expectError    bool
+		expectedWeight int
+	}{
+		{name: "Default weight", weight: "", expectedWeight: 1},
+		{name: "Custom weight", weight: "2", expectedWeight: 2},
+		{name: "Zero weight", weight: "0", expectError: true},
+		{name: "Invalid weight", weight: "abc", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearShardEnvVars()
+			os.Setenv("SHARD_03_URL", "http://shard03:80")
+			os.Setenv("SHARD_03_WEIGHT", tt.weight)
+			defer func() {
+				os.Unsetenv("SHARD_03_URL")
+				os.Unsetenv("SHARD_03_WEIGHT")
+			}()
+
+			shards, err := NewConfigManager().LoadShards()
+
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if shards[0].Weight != tt.expectedWeight {
+				t.Errorf("Expected weight %d, got %d", tt.expectedWeight, shards[0].Weight)
+			}
+		})
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig(t *testing.T) {
+	os.Setenv("HASH_RING_TYPE", "JUMP")
+	defer os.Unsetenv("HASH_RING_TYPE")
+
+	config := NewConfigManager().GetHashRingConfig()
+	if config.Type != "JUMP" {
+		t.Errorf("Expected hash ring type 'JUMP', got '%s'", config.Type)
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig_VirtualNodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected int
+	}{
+		{name: "Custom virtual nodes", envValue: "200", expected: 200},
+		{name: "Default virtual nodes", envValue: "", expected: hashring.DefaultVirtualNodes},
+		{name: "Invalid virtual nodes", envValue: "abc", expected: hashring.DefaultVirtualNodes},
+		{name: "Zero virtual nodes", envValue: "0", expected: hashring.DefaultVirtualNodes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("HASH_RING_VNODES", tt.envValue)
+			defer os.Unsetenv("HASH_RING_VNODES")
+
+			config := NewConfigManager().GetHashRingConfig()
+			if config.Replicas != tt.expected {
+				t.Errorf("Expected %d virtual nodes, got %d", tt.expected, config.Replicas)
+			}
+		})
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig_TableSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected int
+	}{
+		{name: "Valid table size", envValue: "5003", expected: 5003},
+		{name: "Empty table size", envValue: "", expected: 0},
+		{name: "Invalid table size", envValue: "abc", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("HASH_RING_TABLE_SIZE", tt.envValue)
+			defer os.Unsetenv("HASH_RING_TABLE_SIZE")
+
+			config := NewConfigManager().GetHashRingConfig()
+			if config.TableSize != tt.expected {
+				t.Errorf("Expected table size %d, got %d", tt.expected, config.TableSize)
+			}
+		})
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig_LoadFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected float64
+	}{
+		{name: "Valid load factor", envValue: "0.25", expected: 0.25},
+		{name: "Empty load factor", envValue: "", expected: 0},
+		{name: "Invalid load factor", envValue: "abc", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("HASH_RING_LOAD_FACTOR", tt.envValue)
+			defer os.Unsetenv("HASH_RING_LOAD_FACTOR")
+
+			config := NewConfigManager().GetHashRingConfig()
+			if config.LoadFactor != tt.expected {
+				t.Errorf("Expected load factor %.2f, got %.2f", tt.expected, config.LoadFactor)
+			}
+		})
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig_Probes(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected int
+	}{
+		{name: "Valid probes", envValue: "31", expected: 31},
+		{name: "Empty probes", envValue: "", expected: 0},
+		{name: "Invalid probes", envValue: "abc", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("HASH_RING_PROBES", tt.envValue)
+			defer os.Unsetenv("HASH_RING_PROBES")
+
+			config := NewConfigManager().GetHashRingConfig()
+			if config.Probes != tt.expected {
+				t.Errorf("Expected %d probes, got %d", tt.expected, config.Probes)
+			}
+		})
+	}
+}
+
+func TestConfigManagerImpl_GetHashRingConfig_Capacity(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected int
+	}{
+		{name: "Valid capacity", envValue: "4096", expected: 4096},
+		{name: "Empty capacity", envValue: "", expected: 0},
+		{name: "Invalid capacity", envValue: "abc", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("HASH_RING_CAPACITY", tt.envValue)
+			defer os.Unsetenv("HASH_RING_CAPACITY")
+
+			config := NewConfigManager().GetHashRingConfig()
+			if config.Capacity != tt.expected {
+				t.Errorf("Expected capacity %d, got %d", tt.expected, config.Capacity)
+			}
+		})
+	}
+}
+
 func TestSplitEnv(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -278,7 +569,7 @@ func TestInitWithRouter(t *testing.T) {
 	}()
 
 	mockRouter := &MockShardRouter{}
-	err := InitWithRouter(mockRouter)
+	err := InitWithRouter(NewConfigManager(), mockRouter)
 
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
@@ -288,8 +579,9 @@ func TestInitWithRouter(t *testing.T) {
 		t.Error("Expected InitHashRing to be called")
 	}
 
-	if mockRouter.hashRingSize != 2 {
-		t.Errorf("Expected hash ring size 2, got %d", mockRouter.hashRingSize)
+	// O número de réplicas virtuais não depende do número de shards
+	if mockRouter.hashRingSize != hashring.DefaultVirtualNodes {
+		t.Errorf("Expected hash ring size %d, got %d", hashring.DefaultVirtualNodes, mockRouter.hashRingSize)
 	}
 
 	if len(mockRouter.shards) != 2 {
@@ -312,20 +604,98 @@ func TestInitWithRouter(t *testing.T) {
 	}
 }
 
-func TestInitWithRouter_NoShardingKey(t *testing.T) {
-	// Clear SHARDING_KEY
-	oldValue := os.Getenv("SHARDING_KEY")
-	os.Unsetenv("SHARDING_KEY")
-	defer os.Setenv("SHARDING_KEY", oldValue)
+func TestInitWithRouter_Weights(t *testing.T) {
+	os.Setenv("SHARDING_KEY", "user_id")
+	os.Setenv("SHARD_01_URL", "http://shard01:80")
+	os.Setenv("SHARD_02_URL", "http://shard02:80")
+	os.Setenv("SHARD_02_WEIGHT", "2")
+	defer func() {
+		os.Unsetenv("SHARDING_KEY")
+		os.Unsetenv("SHARD_01_URL")
+		os.Unsetenv("SHARD_02_URL")
+		os.Unsetenv("SHARD_02_WEIGHT")
+	}()
+
+	mockRouter := &MockShardRouter{}
+	if err := InitWithRouter(NewConfigManager(), mockRouter); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mockRouter.weights["http://shard01:80"] != 1 {
+		t.Errorf("Expected shard01 weight 1, got %d", mockRouter.weights["http://shard01:80"])
+	}
+	if mockRouter.weights["http://shard02:80"] != 2 {
+		t.Errorf("Expected shard02 weight 2, got %d", mockRouter.weights["http://shard02:80"])
+	}
+}
+
+func TestInitWithRouter_HashRingType(t *testing.T) {
+	os.Setenv("SHARDING_KEY", "user_id")
+	os.Setenv("SHARD_01_URL", "http://shard01:80")
+	os.Setenv("HASH_RING_TYPE", "JUMP")
+	defer func() {
+		os.Unsetenv("SHARDING_KEY")
+		os.Unsetenv("SHARD_01_URL")
+		os.Unsetenv("HASH_RING_TYPE")
+	}()
 
 	mockRouter := &MockShardRouter{}
-	err := InitWithRouter(mockRouter)
+	if err := InitWithRouter(NewConfigManager(), mockRouter); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if mockRouter.hashRingConfig.Type != "JUMP" {
+		t.Errorf("Expected hash ring type 'JUMP', got '%s'", mockRouter.hashRingConfig.Type)
+	}
+}
+
+func TestInitWithRouter_InvalidHashRingType(t *testing.T) {
+	os.Setenv("SHARDING_KEY", "user_id")
+	os.Setenv("SHARD_01_URL", "http://shard01:80")
+	os.Setenv("HASH_RING_TYPE", "INVALID")
+	defer func() {
+		os.Unsetenv("SHARDING_KEY")
+		os.Unsetenv("SHARD_01_URL")
+		os.Unsetenv("HASH_RING_TYPE")
+	}()
+
+	if err := InitWithRouter(NewConfigManager(), nil); err == nil {
+		t.Error("Expected error for unknown HASH_RING_TYPE")
+	}
+}
+
+func TestInitWithRouter_NoShardingKey(t *testing.T) {
+	t.Setenv("SHARDING_KEY", "")
+	t.Setenv("SHARD_01_URL", "http://shard01:80")
 
-	if err == nil {
+	if err := InitWithRouter(NewConfigManager(), nil); err == nil {
 		t.Error("Expected error when SHARDING_KEY is not set")
 	}
 }
 
+func TestInitWithRouter_InvalidShardingKey(t *testing.T) {
+	t.Setenv("SHARDING_KEY", "path:/tenants/orders")
+	t.Setenv("SHARD_01_URL", "http://shard01:80")
+
+	if err := InitWithRouter(NewConfigManager(), nil); err == nil {
+		t.Error("Expected error for invalid SHARDING_KEY")
+	}
+}
+
+func TestInitWithRouter_ProvidedRouterKeepsItsExtractor(t *testing.T) {
+	// O router fornecido já tem o extrator: SHARDING_KEY não é lido novamente
+	t.Setenv("SHARDING_KEY", "")
+	t.Setenv("SHARD_01_URL", "http://shard01:80")
+
+	mockRouter := &MockShardRouter{}
+	if err := InitWithRouter(NewConfigManager(), mockRouter); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(mockRouter.shards) != 1 {
+		t.Errorf("Expected 1 shard added, got %v", mockRouter.shards)
+	}
+}
+
 // Helper function to clear shard environment variables
 func clearShardEnvVars() {
 	for _, env := range os.Environ() {
@@ -334,5 +704,165 @@ func clearShardEnvVars() {
 		if len(key) > 5 && key[:5] == "SHARD" && key[len(key)-4:] == "_URL" {
 			os.Unsetenv(key)
 		}
+		if len(key) > 5 && key[:5] == "SHARD" && strings.HasSuffix(key, "_WEIGHT") {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestInitWithRouter_Snapshot(t *testing.T) {
+	snapshot := interfaces.RingSnapshot{
+		Generation:    3,
+		Type:          "JUMP",
+		HashAlgorithm: "SHA512",
+		Nodes:         []interfaces.SnapshotNode{{ID: "http://shard01:80", Weight: 1}},
+	}
+	data, err := hashring.MarshalSnapshotJSON(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := t.TempDir() + "/ring.json"
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SHARDING_KEY", "user_id")
+	t.Setenv("HASH_RING_SNAPSHOT", path)
+
+	// A descoberta de shards não é usada quando há um snapshot
+	mockRouter := &MockShardRouter{}
+	if err := InitWithRouter(NewConfigManager(), mockRouter); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if mockRouter.snapshot == nil || mockRouter.snapshot.Generation != 3 || mockRouter.snapshot.Nodes[0].ID != "http://shard01:80" {
+		t.Errorf("Expected router to be initialized from snapshot, got %+v", mockRouter.snapshot)
+	}
+	if len(mockRouter.shards) != 0 {
+		t.Errorf("Expected no shards discovered from environment, got %v", mockRouter.shards)
+	}
+}
+
+func TestConfigManagerImpl_LoadHashRingSnapshot(t *testing.T) {
+	t.Setenv("HASH_RING_SNAPSHOT", "")
+	if snapshot, err := NewConfigManager().LoadHashRingSnapshot(); snapshot != nil || err != nil {
+		t.Errorf("Expected no snapshot without HASH_RING_SNAPSHOT, got %v, %v", snapshot, err)
+	}
+
+	t.Setenv("HASH_RING_SNAPSHOT", t.TempDir()+"/missing.json")
+	if _, err := NewConfigManager().LoadHashRingSnapshot(); err == nil {
+		t.Error("Expected error for missing snapshot file")
+	}
+
+	path := t.TempDir() + "/ring.bin"
+	binary := hashring.MarshalSnapshotBinary(interfaces.RingSnapshot{Type: "RENDEZVOUS", HashAlgorithm: "MD5"})
+	if err := os.WriteFile(path, binary, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HASH_RING_SNAPSHOT", path)
+	snapshot, err := NewConfigManager().LoadHashRingSnapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.Type != "RENDEZVOUS" || snapshot.HashAlgorithm != "MD5" {
+		t.Errorf("Unexpected snapshot: %+v", snapshot)
+	}
+}
+
+func TestConfigManagerImpl_GetProxyConfig(t *testing.T) {
+	t.Setenv("PROXY_MAX_IDLE_CONNS_PER_HOST", "256")
+	t.Setenv("PROXY_MAX_CONNS_PER_HOST", "512")
+	t.Setenv("PROXY_IDLE_CONN_TIMEOUT", "2m")
+	t.Setenv("PROXY_DIAL_TIMEOUT", "500ms")
+	t.Setenv("PROXY_KEEP_ALIVE", "")
+	t.Setenv("PROXY_TLS_HANDSHAKE_TIMEOUT", "invalid")
+	t.Setenv("PROXY_RESPONSE_HEADER_TIMEOUT", "15s")
+	t.Setenv("PROXY_FLUSH_INTERVAL", "-1ms")
+	t.Setenv("PROXY_PRESERVE_HOST", "true")
+	t.Setenv("PROXY_BROADCAST_BODY_LIMIT", "65536")
+
+	config := NewConfigManager().GetProxyConfig()
+	expected := interfaces.ProxyConfig{
+		MaxIdleConnsPerHost:   256,
+		MaxConnsPerHost:       512,
+		IdleConnTimeout:       2 * time.Minute,
+		DialTimeout:           500 * time.Millisecond,
+		ResponseHeaderTimeout: 15 * time.Second,
+		FlushInterval:         -time.Millisecond,
+		PreserveHost:          true,
+		BroadcastBodyLimit:    65536,
+	}
+	if config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+}
+
+func TestConfigManagerImpl_GetServerConfig(t *testing.T) {
+	t.Setenv("ROUTER_TLS_CERT", "/etc/router/tls.crt")
+	t.Setenv("ROUTER_TLS_KEY", "/etc/router/tls.key")
+	t.Setenv("ROUTER_GRPC", "true")
+	t.Setenv("ROUTER_ADMIN_PORT", "9090")
+	t.Setenv("ROUTER_ADMIN_TOKEN", "s3cr3t")
+
+	config := NewConfigManager().GetServerConfig()
+	expected := interfaces.ServerConfig{
+		TLSCertFile: "/etc/router/tls.crt",
+		TLSKeyFile:  "/etc/router/tls.key",
+		GRPC:        true,
+		AdminPort:   "9090",
+		AdminToken:  "s3cr3t",
+	}
+	if config != expected {
+		t.Errorf("Expected %+v, got %+v", expected, config)
+	}
+
+	t.Setenv("ROUTER_GRPC", "")
+	if NewConfigManager().GetServerConfig().GRPC {
+		t.Error("Expected gRPC mode to be disabled by default")
+	}
+}
+
+func TestInitWithRouter_MissingKeyPolicy(t *testing.T) {
+	t.Setenv("SHARDING_KEY", "user_id")
+	t.Setenv("SHARD_01_URL", "http://shard01:80")
+	t.Setenv("SHARD_02_URL", "http://shard02:80")
+
+	tests := []struct {
+		policy    string
+		expectErr bool
+	}{
+		{"round_robin", false},
+		{"default:http://shard02:80", false},
+		{"round-robin", true},
+		{"default:http://shard2:80", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			t.Setenv("SHARDING_MISSING_KEY_POLICY", tt.policy)
+			err := InitWithRouter(NewConfigManager(), nil)
+			if tt.expectErr && err == nil {
+				t.Errorf("Expected error for SHARDING_MISSING_KEY_POLICY '%s'", tt.policy)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInitWithRouter_AnchorCapacityTooSmall(t *testing.T) {
+	t.Setenv("SHARDING_KEY", "user_id")
+	t.Setenv("SHARD_01_URL", "http://shard01:80")
+	t.Setenv("SHARD_02_URL", "http://shard02:80")
+	t.Setenv("SHARD_03_URL", "http://shard03:80")
+	t.Setenv("HASH_RING_TYPE", "ANCHOR")
+	t.Setenv("HASH_RING_CAPACITY", "2")
+
+	if err := InitWithRouter(NewConfigManager(), nil); !errors.Is(err, hashring.ErrCapacityExhausted) {
+		t.Fatalf("Expected ErrCapacityExhausted when HASH_RING_CAPACITY is smaller than the number of shards, got %v", err)
+	}
+
+	t.Setenv("HASH_RING_CAPACITY", "3")
+	if err := InitWithRouter(NewConfigManager(), nil); err != nil {
+		t.Errorf("Unexpected error with capacity equal to the number of shards: %v", err)
 	}
 }
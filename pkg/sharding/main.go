@@ -1,50 +1,199 @@
 package sharding
 
 import (
+	"app/pkg/extractor"
 	"app/pkg/hashring"
 	"app/pkg/interfaces"
 	"fmt"
 	"net/http"
 	"os"
+	"sync/atomic"
 )
 
 // ShardRouterImpl implementa a interface ShardRouter
 type ShardRouterImpl struct {
-	hashRing    interfaces.HashRing
-	shardingKey string
+	hashRing   interfaces.HashRing
+	extractor  interfaces.KeyExtractor
+	normalizer KeyNormalizer
+	missingKey *MissingKeyPolicy
+	generation atomic.Uint64
 }
 
 // Garantir que ShardRouterImpl implementa a interface ShardRouter
 var _ interfaces.ShardRouter = (*ShardRouterImpl)(nil)
 
-// NewShardRouter cria uma nova instância de ShardRouter
-func NewShardRouter(shardingKey string) interfaces.ShardRouter {
-	return &ShardRouterImpl{
-		shardingKey: shardingKey,
+// NewShardRouter cria uma nova instância de ShardRouter.
+// shardingKey é a especificação do extrator da chave (veja extractor.Parse); um nome
+// simples é tratado como header. Especificações inválidas retornam o erro de parse.
+func NewShardRouter(shardingKey string) (interfaces.ShardRouter, error) {
+	keyExtractor, err := extractor.Parse(shardingKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sharding key: %w", err)
 	}
+	return NewShardRouterWithExtractor(keyExtractor)
 }
 
-func (sr *ShardRouterImpl) InitHashRing(size int) {
+// NewShardRouterWithExtractor cria um ShardRouter com o extrator da chave já configurado,
+// normalmente obtido de ConfigManager.GetKeyExtractor. A normalização da chave e a política
+// para requisições sem chave são lidas de SHARDING_KEY_NORMALIZATION e SHARDING_MISSING_KEY_POLICY.
+// Valores inválidos retornam erro, em vez de mudar silenciosamente o shard das chaves.
+func NewShardRouterWithExtractor(keyExtractor interfaces.KeyExtractor) (interfaces.ShardRouter, error) {
+	normalizer, err := ParseKeyNormalizer(os.Getenv("SHARDING_KEY_NORMALIZATION"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHARDING_KEY_NORMALIZATION: %w", err)
+	}
+
+	missingKey, err := ParseMissingKeyPolicy(os.Getenv("SHARDING_MISSING_KEY_POLICY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHARDING_MISSING_KEY_POLICY: %w", err)
+	}
+
+	return &ShardRouterImpl{
+		extractor:  keyExtractor,
+		normalizer: normalizer,
+		missingKey: missingKey,
+	}, nil
+}
+
+func (sr *ShardRouterImpl) InitHashRing(config interfaces.HashRingConfig) error {
 	if sr.hashRing == nil {
 		// Importar a função de criação do hashring
-		sr.hashRing = createHashRing(size)
+		hashRing, err := createHashRing(config)
+		if err != nil {
+			return err
+		}
+		sr.hashRing = hashRing
+	}
+	return nil
+}
+
+// InitHashRingFromSnapshot cria o hash ring com a topologia de um snapshot exportado,
+// garantindo o mesmo roteamento do router de origem. A geração continua a do snapshot.
+func (sr *ShardRouterImpl) InitHashRingFromSnapshot(snapshot interfaces.RingSnapshot) error {
+	if sr.hashRing != nil {
+		return fmt.Errorf("hash ring already initialized")
+	}
+	hashRing, err := hashring.NewHashRingFromSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Hash ring loaded from snapshot with %d shards (generation %d)\n", len(snapshot.Nodes), snapshot.Generation)
+	sr.hashRing = hashRing
+	sr.generation.Store(snapshot.Generation)
+	return nil
+}
+
+// Snapshot exporta a topologia atual do hash ring. A geração é incrementada
+// a cada alteração de membros feita pelo router.
+func (sr *ShardRouterImpl) Snapshot() (interfaces.RingSnapshot, error) {
+	ring, ok := sr.hashRing.(interfaces.SnapshotHashRing)
+	if !ok {
+		return interfaces.RingSnapshot{}, fmt.Errorf("hash ring does not support snapshots")
 	}
+	snapshot := ring.Snapshot()
+	snapshot.Generation = sr.generation.Load()
+	return snapshot, nil
 }
 
-func (sr *ShardRouterImpl) AddShard(shardHost string) {
+// AddShard adiciona o shard ao hash ring. Retorna o erro do hash ring quando o shard
+// não pode ser adicionado, como no AnchorHash sem capacidade livre.
+func (sr *ShardRouterImpl) AddShard(shardHost string) error {
 	if sr.hashRing == nil {
 		panic("Hash ring not initialized. Call InitHashRing first.")
 	}
 	fmt.Println("Adding shard to hash ring: ", shardHost)
-	sr.hashRing.AddNode(shardHost)
+	if err := sr.hashRing.AddNode(shardHost); err != nil {
+		return err
+	}
+	sr.generation.Add(1)
+	return nil
 }
 
+// AddWeightedShard adiciona um shard com peso proporcional à sua capacidade.
+// Hash rings que não suportam pesos recebem o shard com peso padrão.
+func (sr *ShardRouterImpl) AddWeightedShard(shardHost string, weight int) error {
+	if sr.hashRing == nil {
+		panic("Hash ring not initialized. Call InitHashRing first.")
+	}
+	ring, ok := sr.hashRing.(interfaces.WeightedHashRing)
+	if !ok {
+		if weight != 1 {
+			fmt.Printf("Hash ring does not support weights, ignoring weight %d for shard %s\n", weight, shardHost)
+		}
+		return sr.AddShard(shardHost)
+	}
+	fmt.Printf("Adding shard to hash ring with weight %d: %s\n", weight, shardHost)
+	if err := ring.AddWeightedNode(shardHost, weight); err != nil {
+		return err
+	}
+	sr.generation.Add(1)
+	return nil
+}
+
+// RemoveShard remove o shard do hash ring em tempo de execução, permitindo
+// descomissionar um shard ou retirar um shard com falha sem reiniciar o router
+func (sr *ShardRouterImpl) RemoveShard(shardHost string) {
+	if sr.hashRing == nil {
+		panic("Hash ring not initialized. Call InitHashRing first.")
+	}
+	fmt.Println("Removing shard from hash ring: ", shardHost)
+	sr.hashRing.RemoveNode(shardHost)
+	sr.generation.Add(1)
+}
+
+// ListShards retorna os shards presentes no hash ring
+func (sr *ShardRouterImpl) ListShards() []string {
+	if sr.hashRing == nil {
+		return []string{}
+	}
+	return sr.hashRing.ListNodes()
+}
+
+// StartRequest informa ao hash ring que uma requisição foi iniciada no shard,
+// permitindo que implementações com bounded loads considerem a carga em andamento
+func (sr *ShardRouterImpl) StartRequest(shardHost string) {
+	if ring, ok := sr.hashRing.(interfaces.LoadAwareHashRing); ok {
+		ring.IncrementLoad(shardHost)
+	}
+}
+
+// FinishRequest informa ao hash ring que uma requisição foi finalizada no shard
+func (sr *ShardRouterImpl) FinishRequest(shardHost string) {
+	if ring, ok := sr.hashRing.(interfaces.LoadAwareHashRing); ok {
+		ring.DecrementLoad(shardHost)
+	}
+}
+
+// GetShardingKey retorna o valor normalizado da chave de sharding da requisição,
+// obtido das fontes configuradas em SHARDING_KEY.
+// Não altera o estado do router, podendo ser chamado concorrentemente.
 func (sr *ShardRouterImpl) GetShardingKey(r *http.Request) string {
-	if sr.shardingKey == "" {
-		// Fallback para variável de ambiente se não foi configurado
-		sr.shardingKey = os.Getenv("SHARDING_KEY")
+	key, _ := sr.LookupShardingKey(r)
+	return key
+}
+
+// LookupShardingKey retorna a chave normalizada e se ela está presente na requisição.
+// Chaves que ficam vazias após a normalização são consideradas ausentes.
+func (sr *ShardRouterImpl) LookupShardingKey(r *http.Request) (string, bool) {
+	key, ok := sr.extractor.Extract(r)
+	key = sr.normalizer.Normalize(key)
+	return key, ok && key != ""
+}
+
+// MissingKeyPolicy retorna o nome da política aplicada às requisições sem chave
+func (sr *ShardRouterImpl) MissingKeyPolicy() string {
+	return sr.missingKey.Name()
+}
+
+// GetMissingKeyShards retorna os shards de destino de uma requisição sem chave conforme
+// a política configurada: nenhum para reject, todos para broadcast e um para as demais
+func (sr *ShardRouterImpl) GetMissingKeyShards() []string {
+	if sr.hashRing == nil {
+		panic("Hash ring not initialized. Call InitHashRing first.")
 	}
-	return r.Header.Get(sr.shardingKey)
+	return sr.missingKey.shards(sr.hashRing.ListNodes(), func() string {
+		return sr.GetShardHost("")
+	})
 }
 
 func (sr *ShardRouterImpl) GetShardHost(key string) string {
@@ -56,8 +205,17 @@ func (sr *ShardRouterImpl) GetShardHost(key string) string {
 	return node
 }
 
+// GetShardHosts retorna até n shards distintos para a chave em ordem de preferência.
+// O primeiro é o dono da chave e os seguintes podem ser usados para failover e réplicas.
+func (sr *ShardRouterImpl) GetShardHosts(key string, n int) []string {
+	if sr.hashRing == nil {
+		panic("Hash ring not initialized. Call InitHashRing first.")
+	}
+	return sr.hashRing.GetNodes(key, n)
+}
+
 // createHashRing é uma função auxiliar para criar o hash ring
 // Isso permite injeção de dependência em testes
-func createHashRing(size int) interfaces.HashRing {
-	return hashring.NewConsistentHashRing(size)
+func createHashRing(config interfaces.HashRingConfig) (interfaces.HashRing, error) {
+	return hashring.NewHashRing(config)
 }
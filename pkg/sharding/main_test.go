@@ -1,7 +1,14 @@
 package sharding
 
 import (
+	"app/pkg/extractor"
+	"app/pkg/hashring"
+	"app/pkg/interfaces"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"sync"
 	"testing"
 )
 
@@ -16,11 +23,32 @@ func (m *MockHashRing) GetHashAlgorithm() string {
 	return "SHA256"
 }
 
-func (m *MockHashRing) AddNode(nodeID string) {
+func (m *MockHashRing) AddNode(nodeID string) error {
 	if m.nodes == nil {
 		m.nodes = make(map[string]bool)
 	}
 	m.nodes[nodeID] = true
+	return nil
+}
+
+func (m *MockHashRing) RemoveNode(nodeID string) {
+	delete(m.nodes, nodeID)
+}
+
+func (m *MockHashRing) ListNodes() []string {
+	nodes := []string{}
+	for node := range m.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (m *MockHashRing) GetNodes(key string, n int) []string {
+	nodes := m.ListNodes()
+	if n < len(nodes) {
+		nodes = nodes[:n]
+	}
+	return nodes
 }
 
 func (m *MockHashRing) GetNode(key string) string {
@@ -38,37 +66,103 @@ func (m *MockHashRing) GetNode(key string) string {
 	return ""
 }
 
-func TestNewShardRouter(t *testing.T) {
-	shardingKey := "user_id"
-	router := NewShardRouter(shardingKey)
+// newTestRouter cria um ShardRouterImpl a partir da especificação da chave, falhando o teste se ela for inválida
+func newTestRouter(t *testing.T, shardingKey string) *ShardRouterImpl {
+	t.Helper()
+	router, err := NewShardRouter(shardingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router.(*ShardRouterImpl)
+}
 
-	if router == nil {
-		t.Fatal("Expected non-nil shard router")
+func TestNewShardRouter(t *testing.T) {
+	router, err := NewShardRouter("user_id")
+	if err != nil {
+		t.Fatal(err)
 	}
 
 	// Type assertion para verificar implementação
 	concreteRouter := router.(*ShardRouterImpl)
-	if concreteRouter.shardingKey != shardingKey {
-		t.Errorf("Expected sharding key to be '%s', got '%s'", shardingKey, concreteRouter.shardingKey)
+	if concreteRouter.extractor.String() != "header:user_id" {
+		t.Errorf("Expected extractor 'header:user_id', got '%s'", concreteRouter.extractor.String())
+	}
+
+	// Especificações inválidas não viram um header com o nome literal
+	if _, err := NewShardRouter("unknown:user_id"); err == nil {
+		t.Error("Expected an error for an invalid sharding key")
+	}
+}
+
+func TestNewShardRouterWithExtractor(t *testing.T) {
+	// SHARDING_KEY é lido apenas pelo ConfigManager
+	os.Setenv("SHARDING_KEY", "tenant_id")
+	defer os.Unsetenv("SHARDING_KEY")
+
+	keyExtractor, err := extractor.Parse(`header:x-region + ":" + header:id_client | cookie:tenant`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shardRouter, err := NewShardRouterWithExtractor(keyExtractor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := shardRouter.(*ShardRouterImpl)
+	if router.extractor != keyExtractor {
+		t.Errorf("Expected extractor '%s', got '%s'", keyExtractor.String(), router.extractor.String())
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("x-region", "EU")
+	req.Header.Set("id_client", "Tenant-A")
+	req.Header.Set("tenant_id", "ignored")
+	if key := router.GetShardingKey(req); key != "eu:tenant-a" {
+		t.Errorf("Expected composite key 'eu:tenant-a', got '%s'", key)
 	}
 }
 
 func TestShardRouterImpl_InitHashRing(t *testing.T) {
-	router := NewShardRouter("user_id").(*ShardRouterImpl)
+	router := newTestRouter(t, "user_id")
 
 	if router.hashRing != nil {
 		t.Error("Expected hash ring to be nil initially")
 	}
 
-	router.InitHashRing(3)
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	if router.hashRing == nil {
 		t.Error("Expected hash ring to be initialized")
 	}
 }
 
+func TestShardRouterImpl_InitHashRing_Jump(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "jump"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, ok := router.hashRing.(*hashring.JumpHashRing); !ok {
+		t.Errorf("Expected JumpHashRing, got %T", router.hashRing)
+	}
+}
+
+func TestShardRouterImpl_InitHashRing_InvalidType(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "INVALID"}); err == nil {
+		t.Error("Expected error for unknown hash ring type")
+	}
+
+	if router.hashRing != nil {
+		t.Error("Expected hash ring to remain nil after failed initialization")
+	}
+}
+
 func TestShardRouterImpl_AddShard(t *testing.T) {
-	router := NewShardRouter("user_id").(*ShardRouterImpl)
+	router := newTestRouter(t, "user_id")
 	mockHashRing := &MockHashRing{}
 	router.hashRing = mockHashRing
 
@@ -80,8 +174,101 @@ func TestShardRouterImpl_AddShard(t *testing.T) {
 	}
 }
 
+func TestShardRouterImpl_AddShard_CapacityExhausted(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "ANCHOR", Capacity: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.AddShard("http://shard01:80"); err != nil {
+		t.Fatal(err)
+	}
+
+	generation := router.generation.Load()
+	if err := router.AddShard("http://shard02:80"); !errors.Is(err, hashring.ErrCapacityExhausted) {
+		t.Errorf("Expected ErrCapacityExhausted, got %v", err)
+	}
+	if router.generation.Load() != generation {
+		t.Error("Expected generation to stay the same when the shard is not added")
+	}
+}
+
+func TestShardRouterImpl_AddWeightedShard(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 10}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	router.AddWeightedShard("http://shard01:80", 1)
+	router.AddWeightedShard("http://shard02:80", 2)
+
+	ring := router.hashRing.(*hashring.ConsistentHashRing)
+	if len(ring.VirtualNodes()) != 30 {
+		t.Errorf("Expected 30 virtual nodes, got %d", len(ring.VirtualNodes()))
+	}
+}
+
+func TestShardRouterImpl_AddWeightedShard_UnweightedRing(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	mockHashRing := &MockHashRing{}
+	router.hashRing = mockHashRing
+
+	router.AddWeightedShard("http://shard01:80", 3)
+
+	if !mockHashRing.nodes["http://shard01:80"] {
+		t.Error("Expected shard to be added to hash ring without weight")
+	}
+}
+
+func TestShardRouterImpl_RemoveShard(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 10}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	router.AddShard("http://shard01:80")
+	router.AddShard("http://shard02:80")
+
+	if shards := router.ListShards(); len(shards) != 2 {
+		t.Fatalf("Expected 2 shards, got %v", shards)
+	}
+
+	router.RemoveShard("http://shard01:80")
+
+	shards := router.ListShards()
+	if len(shards) != 1 || shards[0] != "http://shard02:80" {
+		t.Errorf("Expected only shard02 to remain, got %v", shards)
+	}
+
+	// Todas as chaves passam a ir para o shard restante
+	for _, key := range []string{"user1", "user2", "user3"} {
+		if host := router.GetShardHost(key); host != "http://shard02:80" {
+			t.Errorf("Expected key %s to map to shard02, got %s", key, host)
+		}
+	}
+}
+
+func TestShardRouterImpl_RemoveShard_PanicWithoutInit(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when removing shard without initializing hash ring")
+		}
+	}()
+
+	router.RemoveShard("http://shard01:80")
+}
+
+func TestShardRouterImpl_ListShards_WithoutInit(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+
+	if shards := router.ListShards(); len(shards) != 0 {
+		t.Errorf("Expected no shards, got %v", shards)
+	}
+}
+
 func TestShardRouterImpl_AddShard_PanicWithoutInit(t *testing.T) {
-	router := NewShardRouter("user_id").(*ShardRouterImpl)
+	router := newTestRouter(t, "user_id")
 
 	defer func() {
 		if r := recover(); r == nil {
@@ -92,6 +279,36 @@ func TestShardRouterImpl_AddShard_PanicWithoutInit(t *testing.T) {
 	router.AddShard("http://shard01:80")
 }
 
+func TestShardRouterImpl_RequestLoadTracking(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 3, LoadFactor: 0.25}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	router.AddShard("http://shard01:80")
+
+	ring := router.hashRing.(*hashring.ConsistentHashRing)
+
+	router.StartRequest("http://shard01:80")
+	router.StartRequest("http://shard01:80")
+	if load := ring.GetLoad("http://shard01:80"); load != 2 {
+		t.Errorf("Expected load 2 after starting two requests, got %d", load)
+	}
+
+	router.FinishRequest("http://shard01:80")
+	if load := ring.GetLoad("http://shard01:80"); load != 1 {
+		t.Errorf("Expected load 1 after finishing one request, got %d", load)
+	}
+}
+
+func TestShardRouterImpl_RequestLoadTracking_NotLoadAware(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	router.hashRing = &MockHashRing{}
+
+	// Não deve causar panic em hash rings que não consideram carga
+	router.StartRequest("http://shard01:80")
+	router.FinishRequest("http://shard01:80")
+}
+
 func TestShardRouterImpl_GetShardingKey(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -121,7 +338,7 @@ func TestShardRouterImpl_GetShardingKey(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			router := NewShardRouter(tt.shardingKey)
+			router := newTestRouter(t, tt.shardingKey)
 
 			req, err := http.NewRequest("GET", "/test", nil)
 			if err != nil {
@@ -139,7 +356,7 @@ func TestShardRouterImpl_GetShardingKey(t *testing.T) {
 }
 
 func TestShardRouterImpl_GetShardHost(t *testing.T) {
-	router := NewShardRouter("user_id").(*ShardRouterImpl)
+	router := newTestRouter(t, "user_id")
 
 	expectedShard := "http://shard01:80"
 	mockHashRing := &MockHashRing{
@@ -158,8 +375,41 @@ func TestShardRouterImpl_GetShardHost(t *testing.T) {
 	}
 }
 
+func TestShardRouterImpl_GetShardHosts(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 50}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	router.AddShard("http://shard01:80")
+	router.AddShard("http://shard02:80")
+	router.AddShard("http://shard03:80")
+
+	hosts := router.GetShardHosts("test-key", 2)
+	if len(hosts) != 2 {
+		t.Fatalf("Expected 2 hosts, got %v", hosts)
+	}
+	if hosts[0] != router.GetShardHost("test-key") {
+		t.Errorf("Expected first host to be the key owner %s, got %s", router.GetShardHost("test-key"), hosts[0])
+	}
+	if hosts[0] == hosts[1] {
+		t.Errorf("Expected distinct hosts, got %v", hosts)
+	}
+}
+
+func TestShardRouterImpl_GetShardHosts_PanicWithoutInit(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Expected panic when getting shard hosts without initializing hash ring")
+		}
+	}()
+
+	router.GetShardHosts("test-key", 2)
+}
+
 func TestShardRouterImpl_GetShardHost_PanicWithoutInit(t *testing.T) {
-	router := NewShardRouter("user_id").(*ShardRouterImpl)
+	router := newTestRouter(t, "user_id")
 
 	defer func() {
 		if r := recover(); r == nil {
@@ -172,10 +422,12 @@ func TestShardRouterImpl_GetShardHost_PanicWithoutInit(t *testing.T) {
 
 func TestShardRouterImpl_Integration(t *testing.T) {
 	// Teste de integração completo
-	router := NewShardRouter("user_id")
+	router := newTestRouter(t, "user_id")
 
 	// Inicializar hash ring
-	router.InitHashRing(3)
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
 
 	// Adicionar alguns shards
 	shards := []string{
@@ -217,3 +469,116 @@ func TestShardRouterImpl_Integration(t *testing.T) {
 		}
 	}
 }
+
+// TestShardRouterImpl_ConcurrentRoutingAndMembership deve ser executado com -race
+func TestShardRouterImpl_ConcurrentRoutingAndMembership(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 50}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	router.AddShard("http://shard01:80")
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				req, _ := http.NewRequest("GET", "/test", nil)
+				req.Header.Set("user_id", fmt.Sprintf("user-%d-%d", worker, i))
+
+				host := router.GetShardHost(router.GetShardingKey(req))
+				router.StartRequest(host)
+				router.FinishRequest(host)
+			}
+		}(w)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			router.AddWeightedShard("http://shard02:80", 2)
+			router.ListShards()
+			router.RemoveShard("http://shard02:80")
+		}
+	}()
+
+	wg.Wait()
+}
+
+func TestShardRouterImpl_Snapshot(t *testing.T) {
+	router := newTestRouter(t, "user_id")
+	if _, err := router.Snapshot(); err == nil {
+		t.Error("Expected error before the hash ring is initialized")
+	}
+
+	if err := router.InitHashRing(interfaces.HashRingConfig{Replicas: 10}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	router.AddShard("http://shard01:80")
+	router.AddWeightedShard("http://shard02:80", 2)
+	router.AddShard("http://shard03:80")
+	router.RemoveShard("http://shard03:80")
+
+	snapshot, err := router.Snapshot()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot.Generation != 4 {
+		t.Errorf("Expected generation 4, got %d", snapshot.Generation)
+	}
+	if len(snapshot.Nodes) != 2 || snapshot.Nodes[1].Weight != 2 {
+		t.Errorf("Unexpected snapshot nodes: %+v", snapshot.Nodes)
+	}
+
+	// Uma réplica carregada do snapshot roteia igual e continua a geração
+	replica := newTestRouter(t, "user_id")
+	if err := replica.InitHashRingFromSnapshot(snapshot); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		if replica.GetShardHost(key) != router.GetShardHost(key) {
+			t.Fatalf("Key %s routed differently by the replica", key)
+		}
+	}
+
+	replica.AddShard("http://shard04:80")
+	if restored, _ := replica.Snapshot(); restored.Generation != 5 {
+		t.Errorf("Expected generation 5, got %d", restored.Generation)
+	}
+
+	if err := replica.InitHashRingFromSnapshot(snapshot); err == nil {
+		t.Error("Expected error when the hash ring is already initialized")
+	}
+}
+
+func TestShardRouterImpl_GetShardingKey_Sources(t *testing.T) {
+	os.Unsetenv("SHARDING_KEY_NORMALIZATION")
+
+	tests := []struct {
+		shardingKey string
+		target      string
+		expected    string
+	}{
+		{"query:tenant", "/orders?tenant=Tenant-A", "tenant-a"},
+		{"path:1", "/tenants/Tenant-B/orders", "tenant-b"},
+		{"path:/tenants/{tenant}", "/tenants/tenant-c/orders", "tenant-c"},
+		{"query:tenant", "/orders", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shardingKey, func(t *testing.T) {
+			router := newTestRouter(t, tt.shardingKey)
+			req, err := http.NewRequest("GET", tt.target, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result := router.GetShardingKey(req); result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
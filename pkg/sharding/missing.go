@@ -0,0 +1,89 @@
+package sharding
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+)
+
+// Políticas para requisições sem a chave de sharding
+const (
+	// MissingKeyHash mantém o comportamento histórico: a chave vazia é hasheada
+	// e todas as requisições sem chave vão para o mesmo shard
+	MissingKeyHash = "hash"
+	// MissingKeyReject rejeita a requisição com 400 e um erro em JSON
+	MissingKeyReject = "reject"
+	// MissingKeyDefault envia a requisição para um shard designado (default:<url>)
+	MissingKeyDefault = "default"
+	// MissingKeyRandom envia a requisição para um shard aleatório
+	MissingKeyRandom = "random"
+	// MissingKeyRoundRobin alterna entre os shards
+	MissingKeyRoundRobin = "round_robin"
+	// MissingKeyBroadcast envia a requisição para todos os shards
+	MissingKeyBroadcast = "broadcast"
+)
+
+// DefaultMissingKeyPolicy é a política usada quando SHARDING_MISSING_KEY_POLICY não está definida
+const DefaultMissingKeyPolicy = MissingKeyHash
+
+// MissingKeyPolicy decide para quais shards vão as requisições sem chave de sharding
+type MissingKeyPolicy struct {
+	name         string
+	defaultShard string
+	next         atomic.Uint64
+}
+
+// ParseMissingKeyPolicy cria a política a partir de SHARDING_MISSING_KEY_POLICY:
+// hash, reject, default:<url do shard>, random, round_robin ou broadcast.
+// Uma especificação vazia utiliza DefaultMissingKeyPolicy.
+func ParseMissingKeyPolicy(spec string) (*MissingKeyPolicy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = DefaultMissingKeyPolicy
+	}
+
+	name, arg, _ := strings.Cut(spec, ":")
+	name = strings.ToLower(name)
+	switch name {
+	case MissingKeyHash, MissingKeyReject, MissingKeyRandom, MissingKeyRoundRobin, MissingKeyBroadcast:
+		return &MissingKeyPolicy{name: name}, nil
+	case MissingKeyDefault:
+		if arg == "" {
+			return nil, fmt.Errorf("default policy requires a shard, e.g. default:http://shard01:80")
+		}
+		return &MissingKeyPolicy{name: name, defaultShard: arg}, nil
+	default:
+		return nil, fmt.Errorf("unknown missing key policy '%s'", spec)
+	}
+}
+
+// Name retorna o nome da política, usado como label nas métricas
+func (p *MissingKeyPolicy) Name() string {
+	return p.name
+}
+
+// shards retorna os destinos da requisição sem chave entre os shards informados.
+// hashed é o shard da chave vazia, usado pela política hash.
+func (p *MissingKeyPolicy) shards(shards []string, hashed func() string) []string {
+	switch p.name {
+	case MissingKeyReject:
+		return []string{}
+	case MissingKeyDefault:
+		return []string{p.defaultShard}
+	case MissingKeyBroadcast:
+		return shards
+	}
+
+	if len(shards) == 0 {
+		return []string{}
+	}
+	switch p.name {
+	case MissingKeyRandom:
+		return []string{shards[rand.IntN(len(shards))]}
+	case MissingKeyRoundRobin:
+		return []string{shards[(p.next.Add(1)-1)%uint64(len(shards))]}
+	default:
+		return []string{hashed()}
+	}
+}
@@ -0,0 +1,145 @@
+package sharding
+
+import (
+	"app/pkg/interfaces"
+	"net/http"
+	"testing"
+)
+
+func TestParseMissingKeyPolicy(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+		wantErr  bool
+	}{
+		{spec: "", expected: MissingKeyHash},
+		{spec: "reject", expected: MissingKeyReject},
+		{spec: "ROUND_ROBIN", expected: MissingKeyRoundRobin},
+		{spec: "random", expected: MissingKeyRandom},
+		{spec: "broadcast", expected: MissingKeyBroadcast},
+		{spec: "default:http://shard01:80", expected: MissingKeyDefault},
+		{spec: "default", wantErr: true},
+		{spec: "drop", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			policy, err := ParseMissingKeyPolicy(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy.Name() != tt.expected {
+				t.Errorf("Expected policy '%s', got '%s'", tt.expected, policy.Name())
+			}
+		})
+	}
+}
+
+func newMissingKeyRouter(t *testing.T, policy string) *ShardRouterImpl {
+	t.Helper()
+	t.Setenv("SHARDING_MISSING_KEY_POLICY", policy)
+	router := newTestRouter(t, "user_id")
+	if err := router.InitHashRing(interfaces.HashRingConfig{Type: "RENDEZVOUS"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, shard := range []string{"http://shard01:80", "http://shard02:80", "http://shard03:80"} {
+		router.AddShard(shard)
+	}
+	return router
+}
+
+func TestNewShardRouter_InvalidMissingKeyPolicy(t *testing.T) {
+	// Uma política com erro de digitação não pode virar silenciosamente a política hash
+	t.Setenv("SHARDING_MISSING_KEY_POLICY", "drop")
+	if _, err := NewShardRouter("user_id"); err == nil {
+		t.Error("Expected error for an invalid SHARDING_MISSING_KEY_POLICY")
+	}
+}
+
+func TestShardRouterImpl_GetMissingKeyShards(t *testing.T) {
+	t.Run("hash", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "")
+		shards := router.GetMissingKeyShards()
+		if len(shards) != 1 || shards[0] != router.GetShardHost("") {
+			t.Errorf("Expected the shard of the empty key, got %v", shards)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "reject")
+		if shards := router.GetMissingKeyShards(); len(shards) != 0 {
+			t.Errorf("Expected no shard, got %v", shards)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "default:http://shard02:80")
+		for i := 0; i < 3; i++ {
+			if shards := router.GetMissingKeyShards(); len(shards) != 1 || shards[0] != "http://shard02:80" {
+				t.Errorf("Expected default shard, got %v", shards)
+			}
+		}
+	})
+
+	t.Run("round_robin", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "round_robin")
+		seen := map[string]int{}
+		for i := 0; i < 9; i++ {
+			seen[router.GetMissingKeyShards()[0]]++
+		}
+		for _, shard := range router.ListShards() {
+			if seen[shard] != 3 {
+				t.Errorf("Expected 3 requests on %s, got %d", shard, seen[shard])
+			}
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "random")
+		seen := map[string]bool{}
+		for i := 0; i < 200; i++ {
+			seen[router.GetMissingKeyShards()[0]] = true
+		}
+		if len(seen) != 3 {
+			t.Errorf("Expected requests spread across 3 shards, got %v", seen)
+		}
+	})
+
+	t.Run("broadcast", func(t *testing.T) {
+		router := newMissingKeyRouter(t, "broadcast")
+		if shards := router.GetMissingKeyShards(); len(shards) != 3 {
+			t.Errorf("Expected all shards, got %v", shards)
+		}
+	})
+}
+
+func TestShardRouterImpl_LookupShardingKey(t *testing.T) {
+	t.Setenv("SHARDING_KEY_NORMALIZATION", "strip_prefix:tenant-")
+	router := newTestRouter(t, "user_id")
+
+	tests := []struct {
+		header string
+		key    string
+		ok     bool
+	}{
+		{"tenant-a", "a", true},
+		{"", "", false},
+		// A chave que fica vazia após a normalização é tratada como ausente
+		{"tenant-", "", false},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("user_id", tt.header)
+		key, ok := router.LookupShardingKey(req)
+		if key != tt.key || ok != tt.ok {
+			t.Errorf("Header %q: expected (%q, %v), got (%q, %v)", tt.header, tt.key, tt.ok, key, ok)
+		}
+	}
+}
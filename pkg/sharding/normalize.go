@@ -0,0 +1,75 @@
+package sharding
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultKeyNormalization mantém o comportamento histórico do router, em que
+// chaves com caixas diferentes (Tenant-A e tenant-a) são roteadas para o mesmo shard
+const DefaultKeyNormalization = "lowercase"
+
+// KeyNormalizer aplica, em ordem, as etapas de normalização da chave de sharding
+// antes do hashing. As funções de hash do hash ring são byte-exatas, portanto
+// toda normalização acontece aqui, uma única vez por requisição.
+type KeyNormalizer struct {
+	spec  string
+	steps []func(string) string
+}
+
+// ParseKeyNormalizer cria um KeyNormalizer a partir de uma lista de etapas separadas
+// por vírgula, aplicadas na ordem informada:
+//
+//	none | preserve      mantém a chave exatamente como recebida
+//	lowercase            converte para minúsculas
+//	trim                 remove espaços em branco no início e no fim
+//	nfc                  aplica a normalização Unicode NFC
+//	strip_prefix:<p>     remove o prefixo <p>, se presente
+//
+// Uma especificação vazia utiliza DefaultKeyNormalization.
+func ParseKeyNormalizer(spec string) (KeyNormalizer, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = DefaultKeyNormalization
+	}
+
+	normalizer := KeyNormalizer{spec: spec}
+	for _, step := range strings.Split(spec, ",") {
+		step = strings.TrimSpace(step)
+		name, arg, _ := strings.Cut(step, ":")
+		switch strings.ToLower(name) {
+		case "none", "preserve":
+		case "lowercase":
+			normalizer.steps = append(normalizer.steps, strings.ToLower)
+		case "trim":
+			normalizer.steps = append(normalizer.steps, strings.TrimSpace)
+		case "nfc":
+			normalizer.steps = append(normalizer.steps, norm.NFC.String)
+		case "strip_prefix":
+			if arg == "" {
+				return KeyNormalizer{}, fmt.Errorf("strip_prefix requires a prefix, e.g. strip_prefix:tenant-")
+			}
+			normalizer.steps = append(normalizer.steps, func(s string) string {
+				return strings.TrimPrefix(s, arg)
+			})
+		default:
+			return KeyNormalizer{}, fmt.Errorf("unknown key normalization step '%s'", step)
+		}
+	}
+	return normalizer, nil
+}
+
+// Normalize aplica as etapas configuradas à chave
+func (n KeyNormalizer) Normalize(key string) string {
+	for _, step := range n.steps {
+		key = step(key)
+	}
+	return key
+}
+
+// String retorna a especificação usada para criar o normalizador
+func (n KeyNormalizer) String() string {
+	return n.spec
+}
@@ -0,0 +1,78 @@
+package sharding
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestParseKeyNormalizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		key      string
+		expected string
+	}{
+		{name: "Default lowercases", spec: "", key: "Tenant-A", expected: "tenant-a"},
+		{name: "Preserve case", spec: "none", key: "Tenant-A", expected: "Tenant-A"},
+		{name: "Preserve alias", spec: "preserve", key: " Tenant-A ", expected: " Tenant-A "},
+		{name: "Trim", spec: "trim", key: "  Tenant-A\t", expected: "Tenant-A"},
+		{name: "NFC", spec: "nfc", key: "Jose\u0301", expected: "Jos\u00e9"},
+		{name: "Strip prefix", spec: "strip_prefix:tenant-", key: "tenant-42", expected: "42"},
+		{name: "Prefix absent", spec: "strip_prefix:tenant-", key: "user-42", expected: "user-42"},
+		{name: "Pipeline in order", spec: "trim, lowercase, strip_prefix:tenant-", key: " Tenant-42 ", expected: "42"},
+		{name: "Order matters", spec: "strip_prefix:tenant-,lowercase", key: "Tenant-42", expected: "tenant-42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizer, err := ParseKeyNormalizer(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result := normalizer.Normalize(tt.key); result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestParseKeyNormalizer_Invalid(t *testing.T) {
+	for _, spec := range []string{"uppercase", "lowercase,unknown", "strip_prefix", "strip_prefix:"} {
+		if _, err := ParseKeyNormalizer(spec); err == nil {
+			t.Errorf("Expected error for spec '%s'", spec)
+		}
+	}
+}
+
+func TestNewShardRouter_InvalidNormalization(t *testing.T) {
+	// Um erro de digitação não pode mudar a normalização e mover as chaves de shard
+	t.Setenv("SHARDING_KEY_NORMALIZATION", "preserv")
+	if _, err := NewShardRouter("tenant"); err == nil {
+		t.Error("Expected error for an invalid SHARDING_KEY_NORMALIZATION")
+	}
+}
+
+func TestShardRouterImpl_GetShardingKey_Normalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		expected string
+	}{
+		{name: "Default", env: "", expected: "tenant-a"},
+		{name: "Case preserving", env: "none", expected: "Tenant-A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SHARDING_KEY_NORMALIZATION", tt.env)
+			router := newTestRouter(t, "tenant")
+
+			req, _ := http.NewRequest("GET", "/test", nil)
+			req.Header.Set("tenant", "Tenant-A")
+
+			if result := router.GetShardingKey(req); result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}